@@ -2,15 +2,18 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package feynman
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"strconv"
 	"strings"
 )
 
+//go:generate peg -switch -inline calculator.peg
+
 const (
 	// Operations is the number of operations
 	Operations = 12
@@ -72,8 +75,67 @@ const (
 	OperationNotation
 	// OperationModulus computes the modulus of two numbers
 	OperationModulus
+	// OperationPattern is a pattern variable of a rewrite rule
+	OperationPattern
+	// OperationDerivative is the derivative of the left tree with respect to
+	// the variable on the right
+	OperationDerivative
+	// OperationIntegral is the integral of the left tree with respect to the
+	// variable or bounds on the right
+	OperationIntegral
+	// OperationBounds are the variable and limits of a definite integral
+	OperationBounds
+	// OperationEquation equates the left and right trees, it calculates to
+	// their difference
+	OperationEquation
 )
 
+var operationNames = [...]string{
+	OperationNoop:                  "noop",
+	OperationAdd:                   "add",
+	OperationSubtract:              "subtract",
+	OperationMultiply:              "multiply",
+	OperationDivide:                "divide",
+	OperationExponentiation:        "exponentiation",
+	OperationCosine:                "cosine",
+	OperationSine:                  "sine",
+	OperationNegate:                "negate",
+	OperationNumber:                "number",
+	OperationVariable:              "variable",
+	OperationPI:                    "pi",
+	OperationImaginary:             "imaginary",
+	OperationNaturalExponentiation: "natural_exponentiation",
+	OperationNatural:               "natural",
+	OperationNaturalLogarithm:      "natural_logarithm",
+	OperationSquareRoot:            "square_root",
+	OperationTangent:               "tangent",
+	OperationNotation:              "notation",
+	OperationModulus:               "modulus",
+	OperationPattern:               "pattern",
+	OperationDerivative:            "derivative",
+	OperationIntegral:              "integral",
+	OperationBounds:                "bounds",
+	OperationEquation:              "equation",
+}
+
+// String returns the name of the operation
+func (o Operation) String() string {
+	if int(o) < len(operationNames) {
+		return operationNames[o]
+	}
+	return "Operation(" + strconv.Itoa(int(o)) + ")"
+}
+
+// ParseOperation returns the operation with the name
+func ParseOperation(name string) (Operation, bool) {
+	for i, v := range operationNames {
+		if v == name {
+			return Operation(i), true
+		}
+	}
+	return OperationNoop, false
+}
+
 // IsUnary returns if the operation is unary
 func (o Operation) IsUnary() bool {
 	return o == OperationCosine || o == OperationSine
@@ -496,28 +558,66 @@ func (r Roots) Statistics(m Markov) {
 	}
 }
 
-func (c *Calculator[_]) Tree() *Node {
+// Parse parses an expression into a tree
+func Parse(expression string) (*Node, error) {
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		return nil, err
+	}
+	if err := calc.Parse(); err != nil {
+		return nil, err
+	}
+	return calc.Tree(), nil
+}
+
+func (c *calculator[_]) Tree() *Node {
 	return c.Rulee(c.AST())
 }
 
-func (c *Calculator[U]) Rulee(node *node[U]) *Node {
+func (c *calculator[U]) Rulee(node *node[U]) *Node {
 	node = node.up
+	var a *Node
 	for node != nil {
 		switch node.pegRule {
 		case rulee1:
-			return c.Rulee1(node)
+			a = c.Rulee1(node)
+		case ruleequals:
+			node = node.next
+			b := &Node{}
+			b.Operation = OperationEquation
+			b.Left = a
+			b.Right = c.Rulee1(node)
+			a = b
 		}
 		node = node.next
 	}
-	return nil
+	return a
 }
 
-func (c *Calculator[U]) Rulee1(node *node[U]) *Node {
+func (c *calculator[U]) Rulerewrite(node *node[U]) (*Node, *Node) {
+	node = node.up
+	var lhs, rhs *Node
+	for node != nil {
+		switch node.pegRule {
+		case rulee1, rulep1:
+			if lhs == nil {
+				lhs = c.Rulee1(node)
+			} else {
+				rhs = c.Rulee1(node)
+			}
+		}
+		node = node.next
+	}
+	return lhs, rhs
+}
+
+func (c *calculator[U]) Rulee1(node *node[U]) *Node {
 	node = node.up
 	var a *Node
 	for node != nil {
 		switch node.pegRule {
-		case rulee2:
+		case rulee2, rulep2:
 			a = c.Rulee2(node)
 		case ruleadd:
 			node = node.next
@@ -539,12 +639,12 @@ func (c *Calculator[U]) Rulee1(node *node[U]) *Node {
 	return a
 }
 
-func (c *Calculator[U]) Rulee2(node *node[U]) *Node {
+func (c *calculator[U]) Rulee2(node *node[U]) *Node {
 	node = node.up
 	var a *Node
 	for node != nil {
 		switch node.pegRule {
-		case rulee3:
+		case rulee3, rulep3:
 			a = c.Rulee3(node)
 		case rulemultiply:
 			node = node.next
@@ -573,12 +673,12 @@ func (c *Calculator[U]) Rulee2(node *node[U]) *Node {
 	return a
 }
 
-func (c *Calculator[U]) Rulee3(node *node[U]) *Node {
+func (c *calculator[U]) Rulee3(node *node[U]) *Node {
 	node = node.up
 	var a *Node
 	for node != nil {
 		switch node.pegRule {
-		case rulee4:
+		case rulee4, rulep4:
 			a = c.Rulee4(node)
 		case ruleexponentiation:
 			node = node.next
@@ -593,12 +693,12 @@ func (c *Calculator[U]) Rulee3(node *node[U]) *Node {
 	return a
 }
 
-func (c *Calculator[U]) Rulee4(node *node[U]) *Node {
+func (c *calculator[U]) Rulee4(node *node[U]) *Node {
 	node = node.up
 	minus := false
 	for node != nil {
 		switch node.pegRule {
-		case rulevalue:
+		case rulevalue, rulepvalue:
 			if minus {
 				e := &Node{}
 				e.Operation = OperationNegate
@@ -606,16 +706,20 @@ func (c *Calculator[U]) Rulee4(node *node[U]) *Node {
 				return e
 			}
 			return c.Rulevalue(node)
-		case rulecos:
+		case rulecos, rulepcos:
 			a := &Node{}
 			a.Operation = OperationCosine
 			a.Left = c.Rulecos(node)
 			return a
-		case rulesin:
+		case rulesin, rulepsin:
 			a := &Node{}
 			a.Operation = OperationSine
 			a.Left = c.Rulesin(node)
 			return a
+		case rulediff:
+			return c.Rulediff(node)
+		case ruleintegrate:
+			return c.Ruleintegrate(node)
 		case ruleminus:
 			minus = true
 		}
@@ -624,11 +728,11 @@ func (c *Calculator[U]) Rulee4(node *node[U]) *Node {
 	return nil
 }
 
-func (c *Calculator[U]) Rulecos(node *node[U]) *Node {
+func (c *calculator[U]) Rulecos(node *node[U]) *Node {
 	node = node.up
 	for node != nil {
 		switch node.pegRule {
-		case rulesub:
+		case rulesub, rulepsub:
 			return c.Rulesub(node)
 		}
 		node = node.next
@@ -636,11 +740,11 @@ func (c *Calculator[U]) Rulecos(node *node[U]) *Node {
 	return nil
 }
 
-func (c *Calculator[U]) Rulesin(node *node[U]) *Node {
+func (c *calculator[U]) Rulesin(node *node[U]) *Node {
 	node = node.up
 	for node != nil {
 		switch node.pegRule {
-		case rulesub:
+		case rulesub, rulepsub:
 			return c.Rulesub(node)
 		}
 		node = node.next
@@ -648,7 +752,56 @@ func (c *Calculator[U]) Rulesin(node *node[U]) *Node {
 	return nil
 }
 
-func (c *Calculator[U]) Rulevalue(node *node[U]) *Node {
+func (c *calculator[U]) Rulediff(node *node[U]) *Node {
+	node = node.up
+	a := &Node{}
+	a.Operation = OperationDerivative
+	for node != nil {
+		switch node.pegRule {
+		case rulee1, rulep1:
+			a.Left = c.Rulee1(node)
+		case rulevariable:
+			a.Right = &Node{}
+			a.Right.Operation = OperationVariable
+			a.Right.Variable = strings.TrimSpace(string(c.buffer[node.begin:node.end]))
+		}
+		node = node.next
+	}
+	return a
+}
+
+func (c *calculator[U]) Ruleintegrate(node *node[U]) *Node {
+	node = node.up
+	a := &Node{}
+	a.Operation = OperationIntegral
+	var limits []*Node
+	for node != nil {
+		switch node.pegRule {
+		case rulee1, rulep1:
+			if a.Left == nil {
+				a.Left = c.Rulee1(node)
+			} else {
+				limits = append(limits, c.Rulee1(node))
+			}
+		case rulevariable:
+			a.Right = &Node{}
+			a.Right.Operation = OperationVariable
+			a.Right.Variable = strings.TrimSpace(string(c.buffer[node.begin:node.end]))
+		}
+		node = node.next
+	}
+	if len(limits) == 2 {
+		b := &Node{}
+		b.Operation = OperationBounds
+		b.Variable = a.Right.Variable
+		b.Left = limits[0]
+		b.Right = limits[1]
+		a.Right = b
+	}
+	return a
+}
+
+func (c *calculator[U]) Rulevalue(node *node[U]) *Node {
 	node = node.up
 	for node != nil {
 		switch node.pegRule {
@@ -666,13 +819,18 @@ func (c *Calculator[U]) Rulevalue(node *node[U]) *Node {
 			a.Operation = OperationVariable
 			a.Variable = strings.TrimSpace(string(c.buffer[node.begin:node.end]))
 			return a
+		case rulepattern:
+			a := &Node{}
+			a.Operation = OperationPattern
+			a.Variable = strings.TrimPrefix(strings.TrimSpace(string(c.buffer[node.begin:node.end])), "?")
+			return a
 		case rulepi:
 			a := &Node{}
 			a.Operation = OperationPI
 			a.Variable = "pi"
 			a.Value = math.Pi
 			return a
-		case rulesub:
+		case rulesub, rulepsub:
 			return c.Rulesub(node)
 		}
 		node = node.next
@@ -680,11 +838,11 @@ func (c *Calculator[U]) Rulevalue(node *node[U]) *Node {
 	return nil
 }
 
-func (c *Calculator[U]) Rulesub(node *node[U]) *Node {
+func (c *calculator[U]) Rulesub(node *node[U]) *Node {
 	node = node.up
 	for node != nil {
 		switch node.pegRule {
-		case rulee1:
+		case rulee1, rulep1:
 			return c.Rulee1(node)
 		}
 		node = node.next
@@ -765,7 +923,26 @@ func (n *Node) Derivative(x map[string]bool) *Node {
 			}
 			return a
 		case OperationModulus:
-			return n
+			// u % v = u - v*trunc(u/v) and trunc(u/v) = (u - u%v)/v
+			quotient := &Node{
+				Operation: OperationDivide,
+				Left: &Node{
+					Operation: OperationSubtract,
+					Left:      n.Left,
+					Right:     n,
+				},
+				Right: n.Right,
+			}
+			a := &Node{
+				Operation: OperationSubtract,
+				Left:      process(n.Left),
+				Right: &Node{
+					Operation: OperationMultiply,
+					Left:      quotient,
+					Right:     process(n.Right),
+				},
+			}
+			return a
 		case OperationExponentiation:
 			one := &Node{
 				Operation: OperationNumber,
@@ -791,6 +968,27 @@ func (n *Node) Derivative(x map[string]bool) *Node {
 				Left:      a,
 				Right:     process(n.Left),
 			}
+			if n.Right.dependsOn(x) {
+				// d(u^v) has the term u^v*log(u)*v' when the exponent varies
+				log := &Node{
+					Operation: OperationNaturalLogarithm,
+					Left:      n.Left,
+				}
+				b := &Node{
+					Operation: OperationMultiply,
+					Left: &Node{
+						Operation: OperationMultiply,
+						Left:      n,
+						Right:     log,
+					},
+					Right: process(n.Right),
+				}
+				a = &Node{
+					Operation: OperationAdd,
+					Left:      a,
+					Right:     b,
+				}
+			}
 			return a
 		case OperationNegate:
 			a := &Node{
@@ -918,6 +1116,17 @@ func (n *Node) Derivative(x map[string]bool) *Node {
 				Right:     process(n.Left),
 			}
 			return a
+		case OperationDerivative:
+			return process(n.Left.Derivative(map[string]bool{n.Right.Variable: true}))
+		case OperationIntegral:
+			return n.integralDerivative(x)
+		case OperationEquation:
+			a := &Node{
+				Operation: OperationEquation,
+				Left:      process(n.Left),
+				Right:     process(n.Right),
+			}
+			return a
 		}
 		return nil
 	}
@@ -959,14 +1168,14 @@ func (n *Node) Simplify() *Node {
 			return a
 		case OperationSubtract:
 			left, right := process(n.Left), process(n.Right)
-			if isNumeric(left.Operation) && left.Equals(0) {
+			if isNumeric(right.Operation) && right.Equals(0) {
+				return left
+			} else if isNumeric(left.Operation) && left.Equals(0) {
 				a := &Node{
 					Operation: OperationNegate,
 					Left:      right,
 				}
 				return a
-			} else if isNumeric(right.Operation) && right.Equals(0) {
-				return left
 			}
 			a := &Node{
 				Operation: OperationSubtract,
@@ -1156,13 +1365,47 @@ func (n *Node) Simplify() *Node {
 				Left:      process(n.Left),
 			}
 			return a
+		case OperationDerivative, OperationIntegral, OperationEquation:
+			a := &Node{
+				Operation: n.Operation,
+				Left:      process(n.Left),
+				Right:     process(n.Right),
+			}
+			return a
+		case OperationBounds:
+			a := &Node{
+				Operation: OperationBounds,
+				Variable:  n.Variable,
+				Left:      process(n.Left),
+				Right:     process(n.Right),
+			}
+			return a
 		}
 		return nil
 	}
 	return process(n)
 }
 
+// Calculate evaluates the node with the variables bound to the values in x,
+// where a missing variable is 0. A derivative is differentiated symbolically
+// and then evaluated, a definite integral is computed by quadrature, and an
+// indefinite integral or bounds outside of an integral are NaN. An equation
+// evaluates to the difference of its left and right sides, which is 0 where
+// it holds.
 func (n *Node) Calculate(x map[string]float64) float64 {
+	return n.calculateContext(context.Background(), x)
+}
+
+// CalculateContext is Calculate with the quadrature of definite integrals
+// stopped when the context is done, which returns the error of the context
+func (n *Node) CalculateContext(ctx context.Context, x map[string]float64) (float64, error) {
+	a := n.calculateContext(ctx, x)
+	return a, ctx.Err()
+}
+
+// calculateContext evaluates the node with the variables bound to x and the
+// quadrature of definite integrals bounded by the context
+func (n *Node) calculateContext(ctx context.Context, x map[string]float64) float64 {
 	var a float64
 	switch n.Operation {
 	case OperationNumber:
@@ -1172,21 +1415,43 @@ func (n *Node) Calculate(x map[string]float64) float64 {
 	case OperationPI:
 		a = n.Value
 	case OperationNegate:
-		a = -n.Left.Calculate(x)
+		a = -n.Left.calculateContext(ctx, x)
 	case OperationAdd:
-		a = n.Left.Calculate(x) + n.Right.Calculate(x)
+		a = n.Left.calculateContext(ctx, x) + n.Right.calculateContext(ctx, x)
 	case OperationSubtract:
-		a = n.Left.Calculate(x) - n.Right.Calculate(x)
+		a = n.Left.calculateContext(ctx, x) - n.Right.calculateContext(ctx, x)
 	case OperationMultiply:
-		a = n.Left.Calculate(x) * n.Right.Calculate(x)
+		a = n.Left.calculateContext(ctx, x) * n.Right.calculateContext(ctx, x)
 	case OperationDivide:
-		a = n.Left.Calculate(x) / n.Right.Calculate(x)
+		a = n.Left.calculateContext(ctx, x) / n.Right.calculateContext(ctx, x)
 	case OperationExponentiation:
-		a = math.Pow(n.Left.Calculate(x), n.Right.Calculate(x))
+		a = math.Pow(n.Left.calculateContext(ctx, x), n.Right.calculateContext(ctx, x))
 	case OperationCosine:
-		a = math.Cos(n.Left.Calculate(x))
+		a = math.Cos(n.Left.calculateContext(ctx, x))
 	case OperationSine:
-		a = math.Sin(n.Left.Calculate(x))
+		a = math.Sin(n.Left.calculateContext(ctx, x))
+	case OperationModulus:
+		a = math.Mod(n.Left.calculateContext(ctx, x), n.Right.calculateContext(ctx, x))
+	case OperationTangent:
+		a = math.Tan(n.Left.calculateContext(ctx, x))
+	case OperationSquareRoot:
+		a = math.Sqrt(n.Left.calculateContext(ctx, x))
+	case OperationNaturalLogarithm:
+		a = math.Log(n.Left.calculateContext(ctx, x))
+	case OperationNaturalExponentiation:
+		a = math.Exp(n.Left.calculateContext(ctx, x))
+	case OperationNatural:
+		a = math.E
+	case OperationNotation:
+		a = n.Left.calculateContext(ctx, x) * math.Pow(10, n.Right.calculateContext(ctx, x))
+	case OperationDerivative:
+		a = n.Left.Derivative(map[string]bool{n.Right.Variable: true}).calculateContext(ctx, x)
+	case OperationIntegral:
+		a = n.integral(ctx, x)
+	case OperationBounds:
+		a = math.NaN()
+	case OperationEquation:
+		a = n.Left.calculateContext(ctx, x) - n.Right.calculateContext(ctx, x)
 	}
 	return a
 }
@@ -1222,6 +1487,8 @@ func (n *Node) String() string {
 			return "-(" + process(n.Left) + ")"
 		case OperationVariable:
 			return n.Variable
+		case OperationPattern:
+			return "?" + n.Variable
 		case OperationImaginary:
 			return strconv.FormatFloat(n.Value, 'f', -1, 64) + "i"
 		case OperationNumber:
@@ -1247,6 +1514,14 @@ func (n *Node) String() string {
 			return "sin(" + process(n.Left) + ")"
 		case OperationTangent:
 			return "tan(" + process(n.Left) + ")"
+		case OperationDerivative:
+			return "diff(" + process(n.Left) + ", " + process(n.Right) + ")"
+		case OperationIntegral:
+			return "integrate(" + process(n.Left) + ", " + process(n.Right) + ")"
+		case OperationBounds:
+			return n.Variable + ", " + process(n.Left) + ", " + process(n.Right)
+		case OperationEquation:
+			return process(n.Left) + " = " + process(n.Right)
 		}
 		return ""
 	}
@@ -0,0 +1,283 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultSearchTimeout is the default bound of the antiderivative search
+	// of each integral in Evaluate
+	DefaultSearchTimeout = 2 * time.Second
+	// DefaultSearchDepth is the default depth of the antiderivative search
+	DefaultSearchDepth = 5
+)
+
+// EvaluateOptions are the options for Evaluate
+type EvaluateOptions struct {
+	// Depth is the depth of the antiderivative search, 0 for
+	// DefaultSearchDepth
+	Depth int
+	// SearchTimeout bounds the antiderivative search of each integral, 0 for
+	// DefaultSearchTimeout and negative for only the context
+	SearchTimeout time.Duration
+}
+
+// dependsOn returns if a tree has any of the variables
+func (n *Node) dependsOn(x map[string]bool) bool {
+	for _, name := range n.FreeVariables() {
+		if x[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// integralDerivative differentiates an integral with the fundamental theorem
+// of calculus, and the Leibniz rule when the integral is definite
+func (n *Node) integralDerivative(x map[string]bool) *Node {
+	v := n.Right.Variable
+	inner := make(map[string]bool, len(x))
+	for name, ok := range x {
+		if ok && name != v {
+			inner[name] = true
+		}
+	}
+	var a *Node
+	if n.Left.dependsOn(inner) {
+		a = &Node{
+			Operation: OperationIntegral,
+			Left:      n.Left.Derivative(inner),
+			Right:     n.Right,
+		}
+	}
+	add := func(b *Node, operation Operation) {
+		if a == nil {
+			a = b
+			if operation == OperationSubtract {
+				a = &Node{Operation: OperationNegate, Left: b}
+			}
+			return
+		}
+		a = &Node{Operation: operation, Left: a, Right: b}
+	}
+	if n.Right.Operation == OperationVariable {
+		if x[v] {
+			add(n.Left, OperationAdd)
+		}
+	} else {
+		// f(b)*b' - f(a)*a'
+		bounds := []*Node{n.Right.Right, n.Right.Left}
+		for i, operation := range []Operation{OperationAdd, OperationSubtract} {
+			if !bounds[i].dependsOn(x) {
+				continue
+			}
+			f, _ := n.Left.Substitute(map[string]*Node{v: bounds[i]})
+			add(&Node{
+				Operation: OperationMultiply,
+				Left:      f,
+				Right:     bounds[i].Derivative(x),
+			}, operation)
+		}
+	}
+	if a == nil {
+		return &Node{Operation: OperationNumber, Value: 0.0}
+	}
+	return a
+}
+
+// integral computes a definite integral with QuadContext and the variables
+// bound to x, it is NaN for indefinite integrals
+func (n *Node) integral(ctx context.Context, x map[string]float64) float64 {
+	if n.Right.Operation != OperationBounds {
+		return math.NaN()
+	}
+	v := n.Right.Variable
+	replacements := make(map[string]*Node)
+	for _, name := range n.Left.FreeVariables() {
+		if name != v {
+			replacements[name] = &Node{Operation: OperationNumber, Value: x[name]}
+		}
+	}
+	integrand, err := n.Left.Substitute(replacements)
+	if err != nil {
+		return math.NaN()
+	}
+	r, err := QuadContext(ctx, integrand, v, n.Right.Left.calculateContext(ctx, x), n.Right.Right.calculateContext(ctx, x), 0)
+	if err != nil {
+		return math.NaN()
+	}
+	return r.Value
+}
+
+// antiderivative searches for the antiderivative of an expression in v.
+// Factors and terms that don't depend on v are constants, so other variables
+// may appear in them.
+func antiderivative(ctx context.Context, opts EvaluateOptions, n *Node, v string) (*Node, error) {
+	x := map[string]bool{v: true}
+	if !n.dependsOn(x) {
+		return &Node{
+			Operation: OperationMultiply,
+			Left:      n,
+			Right:     &Node{Operation: OperationVariable, Variable: v},
+		}, nil
+	}
+	free := n.FreeVariables()
+	if len(free) > 1 {
+		// the integral is linear
+		switch n.Operation {
+		case OperationNegate:
+			a, err := antiderivative(ctx, opts, n.Left, v)
+			if err != nil {
+				return nil, err
+			}
+			return &Node{Operation: OperationNegate, Left: a}, nil
+		case OperationAdd, OperationSubtract:
+			a, err := antiderivative(ctx, opts, n.Left, v)
+			if err != nil {
+				return nil, err
+			}
+			b, err := antiderivative(ctx, opts, n.Right, v)
+			if err != nil {
+				return nil, err
+			}
+			return &Node{Operation: n.Operation, Left: a, Right: b}, nil
+		case OperationMultiply:
+			constants, factors := []*Node{}, []*Node{}
+			var split func(n *Node)
+			split = func(n *Node) {
+				switch {
+				case n.Operation == OperationMultiply:
+					split(n.Left)
+					split(n.Right)
+				case n.dependsOn(x):
+					factors = append(factors, n)
+				default:
+					constants = append(constants, n)
+				}
+			}
+			split(n)
+			if len(constants) > 0 {
+				a, err := antiderivative(ctx, opts, product(factors), v)
+				if err != nil {
+					return nil, err
+				}
+				return &Node{Operation: OperationMultiply, Left: product(constants), Right: a}, nil
+			}
+		case OperationDivide:
+			if !n.Right.dependsOn(x) {
+				a, err := antiderivative(ctx, opts, n.Left, v)
+				if err != nil {
+					return nil, err
+				}
+				return &Node{Operation: OperationDivide, Left: a, Right: n.Right}, nil
+			}
+		}
+		return nil, fmt.Errorf("integrate: %s has variables other than %s that aren't constant factors", n, v)
+	}
+	a, err := n.Rename(map[string]string{v: "x"})
+	if err != nil {
+		return nil, err
+	}
+	timeout := opts.SearchTimeout
+	if timeout == 0 {
+		timeout = DefaultSearchTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	a, err = IntegrateContext(ctx, opts.Depth, a.String())
+	if err != nil {
+		return nil, fmt.Errorf("integrate: no antiderivative of %s found: %w", n, err)
+	}
+	return a.Rename(map[string]string{"x": v})
+}
+
+// Evaluate replaces the derivatives of a tree with their simplified forms and
+// the integrals with antiderivatives found by IntegrateContext with the
+// options. A definite integral is F(b) - F(a) when the antiderivative F
+// agrees with Quad, otherwise it is the number computed by Quad.
+func (n *Node) Evaluate(ctx context.Context, opts EvaluateOptions) (*Node, error) {
+	if opts.Depth == 0 {
+		opts.Depth = DefaultSearchDepth
+	}
+	var process func(n *Node) (*Node, error)
+	process = func(n *Node) (*Node, error) {
+		if n == nil {
+			return nil, nil
+		}
+		if n.Operation == OperationDerivative {
+			// differentiating first removes integrals with variable bounds
+			a, err := process(n.Left.Derivative(map[string]bool{n.Right.Variable: true}))
+			if err != nil {
+				return nil, err
+			}
+			if a = a.Simplify(); a == nil {
+				return nil, fmt.Errorf("diff: can't differentiate %s", n.Left)
+			}
+			return a, nil
+		}
+		left, err := process(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Operation {
+		case OperationIntegral:
+			v := n.Right.Variable
+			if n.Right.Operation == OperationVariable {
+				return antiderivative(ctx, opts, left, v)
+			}
+			lower, err := process(n.Right.Left)
+			if err != nil {
+				return nil, err
+			}
+			upper, err := process(n.Right.Right)
+			if err != nil {
+				return nil, err
+			}
+			constant := len(lower.FreeVariables()) == 0 && len(upper.FreeVariables()) == 0
+			if f, err := antiderivative(ctx, opts, left, v); err == nil {
+				fa, _ := f.Substitute(map[string]*Node{v: lower})
+				fb, _ := f.Substitute(map[string]*Node{v: upper})
+				a := (&Node{Operation: OperationSubtract, Left: fb, Right: fa}).Simplify()
+				if !constant {
+					return a, nil
+				}
+				r, err := QuadContext(ctx, left, v, lower.calculateContext(ctx, nil), upper.calculateContext(ctx, nil), 0)
+				if err == nil {
+					if _, ok, err := r.Check(f); err == nil && ok {
+						return a, nil
+					}
+				}
+			}
+			if !constant {
+				return nil, errors.New("integrate: bounds aren't constant")
+			}
+			r, err := QuadContext(ctx, left, v, lower.calculateContext(ctx, nil), upper.calculateContext(ctx, nil), 0)
+			if err != nil {
+				return nil, err
+			}
+			return &Node{Operation: OperationNumber, Value: r.Value}, nil
+		}
+		right, err := process(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		if left == n.Left && right == n.Right {
+			return n, nil
+		}
+		a := *n
+		a.Left, a.Right = left, right
+		return &a, nil
+	}
+	return process(n)
+}
@@ -0,0 +1,454 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pointlander/feynman"
+)
+
+const (
+	// ExitOK is the exit code for success
+	ExitOK = 0
+	// ExitError is the exit code for parse and runtime errors
+	ExitError = 1
+	// ExitUsage is the exit code for invalid usage
+	ExitUsage = 2
+	// ExitConvergence is the exit code for a fit that did not converge
+	ExitConvergence = 3
+)
+
+const usage = `usage: feynman <command> [flags] [expression|-] [name=value ...]
+
+The expression is read from stdin if it is missing or "-".
+
+commands:
+  eval       evaluate an expression
+  diff       differentiate an expression
+  simplify   simplify an expression
+  integrate  find an antiderivative of an expression in x
+  fit        fit the parameters of a model with gradient based optimization
+  regress    fit the parameters of a model with Levenberg-Marquardt
+  repl       start an interactive session
+  serve      serve the expression engine over http
+`
+
+// Output is the output of a command
+type Output struct {
+	Expression     string             `json:"expression,omitempty"`
+	Result         *feynman.Node      `json:"-"`
+	Value          *float64           `json:"value,omitempty"`
+	Values         map[string]float64 `json:"values,omitempty"`
+	StandardErrors map[string]float64 `json:"standard_errors,omitempty"`
+	Loss           *float64           `json:"loss,omitempty"`
+	Iterations     int                `json:"iterations,omitempty"`
+	Converged      *bool              `json:"converged,omitempty"`
+}
+
+// Command is a command line context
+type Command struct {
+	Name   string
+	Flags  *flag.FlagSet
+	Format *string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewCommand creates a new command with the common flags
+func NewCommand(name string, stdin io.Reader, stdout, stderr io.Writer) *Command {
+	c := &Command{
+		Name:   name,
+		Flags:  flag.NewFlagSet(name, flag.ContinueOnError),
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+	c.Flags.SetOutput(stderr)
+	c.Format = c.Flags.String("format", "text", "output format: text, json, latex or dot")
+	return c
+}
+
+// Arguments parses the expression and variable bindings from the positional arguments
+func (c *Command) Arguments() (*feynman.Node, map[string]float64, error) {
+	args := c.Flags.Args()
+	expression := "-"
+	if len(args) > 0 {
+		expression, args = args[0], args[1:]
+	}
+	if expression == "-" {
+		input, err := io.ReadAll(c.Stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+		expression = strings.TrimSpace(string(input))
+	}
+	n, err := feynman.Parse(expression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: parse error in %q", c.Name, expression)
+	}
+	values, err := Bindings(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, values, nil
+}
+
+// unbound returns an error for the first free variable of a tree without a
+// value, which Calculate would evaluate as 0
+func unbound(n *feynman.Node, values map[string]float64) error {
+	for _, name := range n.FreeVariables() {
+		if _, ok := values[name]; !ok {
+			return fmt.Errorf("unbound variable %s", name)
+		}
+	}
+	return nil
+}
+
+// Bindings parses name=value variable bindings
+func Bindings(args []string) (map[string]float64, error) {
+	values := make(map[string]float64)
+	for _, arg := range args {
+		name, value, found := strings.Cut(arg, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid binding %q", arg)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binding %q", arg)
+		}
+		values[strings.TrimSpace(name)] = v
+	}
+	return values, nil
+}
+
+// ReadDataset reads a dataset from a csv file with a header row of variable names
+func ReadDataset(r io.Reader) (feynman.Dataset, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, errors.New("dataset has no rows")
+	}
+	header := records[0]
+	data := make(feynman.Dataset, 0, len(records)-1)
+	for _, record := range records[1:] {
+		datum := make(map[string]float64, len(header))
+		for i, name := range header {
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				return nil, err
+			}
+			datum[strings.TrimSpace(name)] = v
+		}
+		data = append(data, datum)
+	}
+	return data, nil
+}
+
+// Write writes the output in the selected format
+func (c *Command) Write(o *Output) error {
+	switch *c.Format {
+	case "json":
+		if o.Result != nil {
+			o.Expression = o.Result.String()
+		}
+		encoder := json.NewEncoder(c.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(o)
+	case "dot":
+		if o.Result == nil {
+			return errors.New("dot format requires an expression result")
+		}
+		return feynman.WriteDOT(c.Stdout, o.Result, true)
+	case "text", "latex":
+		latex := *c.Format == "latex"
+		if o.Result != nil {
+			if latex {
+				fmt.Fprintln(c.Stdout, o.Result.LaTeX())
+			} else {
+				fmt.Fprintln(c.Stdout, o.Result.String())
+			}
+		}
+		if o.Value != nil {
+			fmt.Fprintln(c.Stdout, strconv.FormatFloat(*o.Value, 'g', -1, 64))
+		}
+		names := make([]string, 0, len(o.Values))
+		for name := range o.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			label, value := name, strconv.FormatFloat(o.Values[name], 'g', -1, 64)
+			if latex {
+				label = (&feynman.Node{Operation: feynman.OperationVariable, Variable: name}).LaTeX()
+			}
+			if se, ok := o.StandardErrors[name]; ok {
+				if latex {
+					value += ` \pm ` + strconv.FormatFloat(se, 'g', -1, 64)
+				} else {
+					value += " +/- " + strconv.FormatFloat(se, 'g', -1, 64)
+				}
+			}
+			fmt.Fprintf(c.Stdout, "%s = %s\n", label, value)
+		}
+		if o.Loss != nil {
+			fmt.Fprintf(c.Stdout, "loss = %s\n", strconv.FormatFloat(*o.Loss, 'g', -1, 64))
+		}
+		if o.Converged != nil {
+			fmt.Fprintf(c.Stdout, "iterations = %d\nconverged = %t\n", o.Iterations, *o.Converged)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", *c.Format)
+}
+
+// fail reports an error and returns the exit code
+func (c *Command) fail(err error, code int) int {
+	fmt.Fprintln(c.Stderr, err)
+	return code
+}
+
+// variables splits a comma separated list of names
+func variables(list string) []string {
+	names := []string{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Run runs the command line and returns the exit code
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+	c := NewCommand(args[0], stdin, stdout, stderr)
+	var execute func() (*Output, error)
+	switch c.Name {
+	case "eval":
+		execute = func() (*Output, error) {
+			n, values, err := c.Arguments()
+			if err != nil {
+				return nil, err
+			}
+			if err := unbound(n, values); err != nil {
+				return nil, err
+			}
+			value := n.Calculate(values)
+			return &Output{Value: &value}, nil
+		}
+	case "diff":
+		x := c.Flags.String("x", "x", "comma separated variables to differentiate with respect to")
+		execute = func() (*Output, error) {
+			n, _, err := c.Arguments()
+			if err != nil {
+				return nil, err
+			}
+			with := make(map[string]bool)
+			for _, name := range variables(*x) {
+				with[name] = true
+			}
+			return &Output{Result: n.Derivative(with).Simplify()}, nil
+		}
+	case "simplify":
+		execute = func() (*Output, error) {
+			n, _, err := c.Arguments()
+			if err != nil {
+				return nil, err
+			}
+			return &Output{Result: n.Simplify()}, nil
+		}
+	case "integrate":
+		depth := c.Flags.Int("depth", 5, "depth of the search")
+		timeout := c.Flags.Duration("timeout", 0, "maximum duration of the search, 0 for no limit")
+		execute = func() (*Output, error) {
+			n, _, err := c.Arguments()
+			if err != nil {
+				return nil, err
+			}
+			ctx := context.Background()
+			if *timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, *timeout)
+				defer cancel()
+			}
+			a, err := feynman.IntegrateContext(ctx, *depth, n.String())
+			if err != nil {
+				return nil, err
+			}
+			return &Output{Result: a}, nil
+		}
+	case "fit", "regress":
+		params := c.Flags.String("params", "", "comma separated parameters to fit")
+		target := c.Flags.String("target", feynman.DefaultTarget, "variable holding the observed value")
+		file := c.Flags.String("data", "", "csv file of observations with a header row, - for stdin")
+		iterations := c.Flags.Int("iterations", 0, "maximum number of iterations")
+		var optimizer *string
+		var rate, tolerance *float64
+		if c.Name == "fit" {
+			optimizer = c.Flags.String("optimizer", "descent", "optimizer: descent, momentum, adam or lbfgs")
+			rate = c.Flags.Float64("rate", feynman.DefaultLearningRate, "learning rate")
+			tolerance = c.Flags.Float64("tolerance", feynman.DefaultTolerance, "gradient length tolerance")
+		}
+		execute = func() (*Output, error) {
+			if *file == "" {
+				return nil, errors.New("missing -data")
+			}
+			var data feynman.Dataset
+			if *file == "-" {
+				d, err := ReadDataset(c.Stdin)
+				if err != nil {
+					return nil, err
+				}
+				data = d
+			} else {
+				input, err := os.Open(*file)
+				if err != nil {
+					return nil, err
+				}
+				defer input.Close()
+				d, err := ReadDataset(input)
+				if err != nil {
+					return nil, err
+				}
+				data = d
+			}
+			n, values, err := c.Arguments()
+			if err != nil {
+				return nil, err
+			}
+			initial := make(map[string]float64)
+			for _, name := range variables(*params) {
+				if v, ok := values[name]; ok {
+					initial[name] = v
+					delete(values, name)
+				}
+			}
+			for _, datum := range data {
+				for name, v := range values {
+					if _, ok := datum[name]; !ok {
+						datum[name] = v
+					}
+				}
+			}
+			if c.Name == "regress" {
+				result, err := feynman.LevenbergMarquardt([]*feynman.Node{feynman.Residual(n, *target)}, variables(*params), data, feynman.LeastSquaresOptions{
+					Iterations: *iterations,
+					Initial:    initial,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return &Output{
+					Values:         result.Values,
+					StandardErrors: result.StandardErrors,
+					Loss:           &result.SumSquares,
+					Iterations:     result.Iterations,
+					Converged:      &result.Converged,
+				}, nil
+			}
+			opts := feynman.FitOptions{
+				Target:       *target,
+				LearningRate: *rate,
+				Iterations:   *iterations,
+				Tolerance:    *tolerance,
+				Initial:      initial,
+			}
+			switch *optimizer {
+			case "descent":
+			case "momentum":
+				opts.Optimizer = &feynman.Momentum{Rate: *rate, Beta: .9}
+			case "adam":
+				opts.Optimizer = feynman.NewAdam(*rate)
+			case "lbfgs":
+				opts.Optimizer = &feynman.LBFGS{}
+			default:
+				return nil, fmt.Errorf("unknown optimizer %q", *optimizer)
+			}
+			result, err := feynman.Fit(n, variables(*params), data, opts)
+			if err != nil {
+				return nil, err
+			}
+			return &Output{
+				Values:     result.Values,
+				Loss:       &result.Loss,
+				Iterations: result.Iterations,
+				Converged:  &result.Converged,
+			}, nil
+		}
+	case "repl":
+		history := c.Flags.String("history", DefaultHistory(), "history file, empty to disable")
+		depth := c.Flags.Int("depth", 5, "depth of the integration search")
+		timeout := c.Flags.Duration("timeout", DefaultTimeout, "maximum duration of a statement, 0 for no limit")
+		search := c.Flags.Duration("search", feynman.DefaultSearchTimeout, "maximum duration of the search for each antiderivative")
+		execute = func() (*Output, error) {
+			h, err := LoadHistory(*history)
+			if err != nil {
+				return nil, err
+			}
+			s := NewSession()
+			s.Depth, s.Timeout, s.SearchTimeout = *depth, *timeout, *search
+			return nil, REPL(s, h, c.Stdin, c.Stdout)
+		}
+	case "serve":
+		addr := c.Flags.String("addr", "localhost:8080", "address to listen on")
+		timeout := c.Flags.Duration("timeout", DefaultTimeout, "maximum duration of a request")
+		max := c.Flags.Int("max", DefaultMaxExpression, "maximum length of an expression in bytes")
+		execute = func() (*Output, error) {
+			server := &http.Server{
+				Addr: *addr,
+				Handler: NewServer(ServerOptions{
+					Timeout:       *timeout,
+					MaxExpression: *max,
+				}),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			return nil, server.ListenAndServe()
+		}
+	default:
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+	if err := c.Flags.Parse(args[1:]); err != nil {
+		return ExitUsage
+	}
+	switch *c.Format {
+	case "text", "json", "latex", "dot":
+	default:
+		return c.fail(fmt.Errorf("unknown format %q", *c.Format), ExitUsage)
+	}
+	o, err := execute()
+	if err != nil {
+		return c.fail(err, ExitError)
+	}
+	if o == nil {
+		return ExitOK
+	}
+	if err := c.Write(o); err != nil {
+		return c.fail(err, ExitError)
+	}
+	if o.Converged != nil && !*o.Converged {
+		return ExitConvergence
+	}
+	return ExitOK
+}
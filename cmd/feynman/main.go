@@ -0,0 +1,14 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command feynman evaluates, differentiates, simplifies, integrates and fits expressions
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	os.Exit(Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
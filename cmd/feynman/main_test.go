@@ -0,0 +1,130 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		output string
+	}{
+		{[]string{"eval", "x^2 + y", "x=3", "y=1"}, "", ExitOK, "10\n"},
+		{[]string{"eval", "-", "x=2"}, "x*x", ExitOK, "4\n"},
+		{[]string{"diff", "-x", "y", "y*y + x"}, "", ExitOK, "(y + y)\n"},
+		{[]string{"simplify", "-format", "json", "0 + x*1"}, "", ExitOK, "{\n  \"expression\": \"x\"\n}\n"},
+		{[]string{"eval", "x +"}, "", ExitError, ""},
+		{[]string{"eval", "x^2 + y", "x=3"}, "", ExitError, ""},
+		{[]string{"eval", "integrate(x*y, y, 0, 1)", "x=2"}, "", ExitOK, "1\n"},
+		{[]string{"eval", "-format", "xml", "x"}, "", ExitUsage, ""},
+		{[]string{"unknown"}, "", ExitUsage, ""},
+		{[]string{"regress", "-params", "a,b", "-data", "-", "a*x + b"}, "x,y\n0,1\n1,3\n2,5\n", ExitOK, ""},
+		{[]string{"fit", "-params", "a,b", "-iterations", "1", "-data", "-", "a*x + b"}, "x,y\n0,1\n1,3\n2,5\n", ExitConvergence, ""},
+	}
+	for _, test := range tests {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		code := Run(test.args, strings.NewReader(test.stdin), stdout, stderr)
+		if code != test.code {
+			t.Fatal(test.args, "got incorrect exit code", code, stderr.String())
+		}
+		if test.output != "" && stdout.String() != test.output {
+			t.Fatal(test.args, "got incorrect output", stdout.String())
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	s := NewSession()
+	statements := []struct {
+		statement string
+		result    string
+	}{
+		{"f := x^2*sin(x)", "f := ((x^2) * sin(x))"},
+		{"g := f*2", "g := (((x^2) * sin(x)) * 2)"},
+		{"diff(x^3, x)", "(3 * (x^(3 - 1)))"},
+		{"simplify(0 + g*1)", "(((x^2) * sin(x)) * 2)"},
+		{"eval(f, x=2)", strconv.FormatFloat(4*math.Sin(2), 'f', -1, 64)},
+		{"names", "f := ((x^2) * sin(x))\ng := (((x^2) * sin(x)) * 2)"},
+		{"y = 2*f", "y = (2 * ((x^2) * sin(x)))"},
+		{"x^2 = 4", "(x^2) = 4"},
+		{"diff(x, x) + diff(x^2, x)", "(1 + (2 * (x^(2 - 1))))"},
+		{"2*diff(x^2, x)", "(2 * (2 * (x^(2 - 1))))"},
+		{"integrate(x^2*sin(x), x, 0, pi)", strconv.FormatFloat(math.Pi*math.Pi-4, 'f', -1, 64)},
+	}
+	for _, v := range statements {
+		result, err := s.Execute(v.statement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != v.result {
+			t.Fatalf("%s got %s", v.statement, result)
+		}
+	}
+	if _, err := s.Execute("eval(f + z, x=2)"); err == nil || !strings.Contains(err.Error(), "unbound variable z") {
+		t.Fatal("expected an unbound variable", err)
+	}
+	if _, ok := s.Names["y"]; ok {
+		t.Fatal("an equation defined a name")
+	}
+	if _, err := s.Execute("diff(x"); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if _, err := s.Execute("pi := 3"); err == nil {
+		t.Fatal("expected an error assigning to pi")
+	}
+	// an indefinite integral of a named variable can't be expanded
+	if _, err := s.Execute("u := 2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Execute("integrate(u, u) + 1"); err == nil {
+		t.Fatal("expected an expansion error")
+	}
+	// the derivative of x^x prints with log, which doesn't parse
+	if _, err := s.Execute("h := x^x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Execute("integrate(diff(h, x), x)"); err == nil {
+		t.Fatal("expected an integration error")
+	}
+	s.Timeout = time.Millisecond
+	if _, err := s.Execute("integrate(x^7*sin(x), x)"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected the search to time out", err)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := strings.NewReader("f := x*\\\n2\nsimplify(diff(f,\nx))\nquit\n")
+	out := &bytes.Buffer{}
+	if err := REPL(NewSession(), h, in, out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "> . 2\n") {
+		t.Fatal("got incorrect output", out.String())
+	}
+	h, err = LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Entries) != 2 || h.Entries[1] != "simplify(diff(f,\nx))" {
+		t.Fatal("got incorrect history", h.Entries)
+	}
+}
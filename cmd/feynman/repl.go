@@ -0,0 +1,375 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pointlander/feynman"
+)
+
+const help = `statements:
+  name := expression        define a named expression
+  expression                expand the named expressions in an expression and
+                            evaluate its derivatives and integrals
+  e1 = e2                   an equation, which isn't a definition
+  diff(e, x)                differentiate e with respect to x
+  integrate(e, x[, a, b])   find an antiderivative of e in x, or integrate
+                            it from a to b
+  simplify(e)               simplify e
+  eval(e[, x=3, ...])       evaluate e with the variables bound
+commands:
+  names                     list the named expressions
+  clear name                remove a named expression
+  history                   list the history
+  !n                        repeat history entry n
+  help                      show this help
+  quit                      exit
+A statement with unbalanced parentheses or a trailing \ continues on the next line.
+`
+
+var (
+	assignment = regexp.MustCompile(`^\s*([a-z]+[0-9]*)\s*:=(.*)$`)
+	callee     = regexp.MustCompile(`^\s*([a-z]+)\s*\(`)
+)
+
+// Session is the environment of an interactive session
+type Session struct {
+	// Names are the named expressions
+	Names map[string]*feynman.Node
+	// Depth is the depth of the integration search
+	Depth int
+	// Timeout is the maximum duration of a statement, 0 for no limit
+	Timeout time.Duration
+	// SearchTimeout is the maximum duration of the search for each
+	// antiderivative
+	SearchTimeout time.Duration
+}
+
+// NewSession creates a new session
+func NewSession() *Session {
+	return &Session{
+		Names:         make(map[string]*feynman.Node),
+		Depth:         5,
+		Timeout:       DefaultTimeout,
+		SearchTimeout: feynman.DefaultSearchTimeout,
+	}
+}
+
+// Expand replaces the named expressions in a tree
+func (s *Session) Expand(n *feynman.Node) (*feynman.Node, error) {
+	return s.expand(n, make(map[string]bool))
+}
+
+// expand replaces the named expressions in a tree that aren't being expanded
+func (s *Session) expand(n *feynman.Node, expanding map[string]bool) (*feynman.Node, error) {
+	replacements := make(map[string]*feynman.Node)
+	for _, name := range n.FreeVariables() {
+		if e, ok := s.Names[name]; ok && !expanding[name] {
+			expanding[name] = true
+			a, err := s.expand(e, expanding)
+			if err != nil {
+				return nil, err
+			}
+			replacements[name] = a
+			expanding[name] = false
+		}
+	}
+	return n.Substitute(replacements)
+}
+
+// call splits a statement that is a single top level call into the name and
+// the arguments, the parenthesis matching the first one must end the statement
+func call(statement string) (string, string, bool) {
+	m := callee.FindStringSubmatchIndex(statement)
+	if m == nil {
+		return "", "", false
+	}
+	statement = strings.TrimSpace(statement)
+	start := strings.Index(statement, "(")
+	depth := 0
+	for i := start; i < len(statement); i++ {
+		switch statement[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				if i != len(statement)-1 {
+					return "", "", false
+				}
+				return strings.TrimSpace(statement[:start]), statement[start+1 : i], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// split splits the arguments of a call at the top level commas
+func split(arguments string) []string {
+	args, depth, last := []string{}, 0, 0
+	for i, r := range arguments {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(arguments[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(arguments[last:]); rest != "" || len(args) > 0 {
+		args = append(args, rest)
+	}
+	return args
+}
+
+// context returns the context of a search or quadrature, which ends after
+// the timeout or at an interrupt that stops it rather than the session
+func (s *Session) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if s.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// Evaluate evaluates an expression with its derivatives and integrals, or a
+// call to one of the session functions
+func (s *Session) Evaluate(statement string) (*feynman.Node, error) {
+	if name, arguments, ok := call(statement); ok {
+		args := split(arguments)
+		switch name {
+		case "simplify":
+			if len(args) != 1 {
+				return nil, errors.New("simplify: expected an expression")
+			}
+			e, err := s.Evaluate(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return e.Simplify(), nil
+		case "eval":
+			if len(args) < 1 {
+				return nil, errors.New("eval: expected an expression")
+			}
+			e, err := s.Evaluate(args[0])
+			if err != nil {
+				return nil, err
+			}
+			values, err := Bindings(args[1:])
+			if err != nil {
+				return nil, err
+			}
+			if err := unbound(e, values); err != nil {
+				return nil, fmt.Errorf("eval: %w", err)
+			}
+			ctx, cancel := s.context()
+			defer cancel()
+			value, err := e.CalculateContext(ctx, values)
+			if err != nil {
+				return nil, fmt.Errorf("eval: %w", err)
+			}
+			return &feynman.Node{
+				Operation: feynman.OperationNumber,
+				Value:     value,
+			}, nil
+		}
+	}
+	n, err := feynman.Parse(statement)
+	if err != nil {
+		return nil, fmt.Errorf("parse error in %q", strings.TrimSpace(statement))
+	}
+	e, err := s.Expand(n)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	return e.Evaluate(ctx, feynman.EvaluateOptions{Depth: s.Depth, SearchTimeout: s.SearchTimeout})
+}
+
+// Execute executes a statement and returns the text of the result
+func (s *Session) Execute(statement string) (string, error) {
+	statement = strings.ReplaceAll(statement, "\n", " ")
+	if m := assignment.FindStringSubmatch(statement); m != nil {
+		if m[1] == feynman.Reserved {
+			return "", fmt.Errorf("can't assign to %s", feynman.Reserved)
+		}
+		e, err := s.Evaluate(m[2])
+		if err != nil {
+			return "", err
+		}
+		s.Names[m[1]] = e
+		return m[1] + " := " + e.String(), nil
+	}
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	switch fields[0] {
+	case "names":
+		names := make([]string, 0, len(s.Names))
+		for name := range s.Names {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines := make([]string, len(names))
+		for i, name := range names {
+			lines[i] = name + " := " + s.Names[name].String()
+		}
+		return strings.Join(lines, "\n"), nil
+	case "clear":
+		for _, name := range fields[1:] {
+			delete(s.Names, name)
+		}
+		return "", nil
+	case "help":
+		return strings.TrimSuffix(help, "\n"), nil
+	}
+	e, err := s.Evaluate(statement)
+	if err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+// History is the statement history of a REPL, stored one quoted entry per line
+type History struct {
+	Entries []string
+	Path    string
+}
+
+// LoadHistory loads the history from a file, a missing file is an empty history
+func LoadHistory(path string) (*History, error) {
+	h := &History{Path: path}
+	if path == "" {
+		return h, nil
+	}
+	input, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		entry, err := strconv.Unquote(scanner.Text())
+		if err != nil {
+			continue
+		}
+		h.Entries = append(h.Entries, entry)
+	}
+	return h, scanner.Err()
+}
+
+// Add adds an entry to the history and appends it to the history file
+func (h *History) Add(entry string) error {
+	h.Entries = append(h.Entries, entry)
+	if h.Path == "" {
+		return nil
+	}
+	output, err := os.OpenFile(h.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, strconv.Quote(entry))
+	if err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// DefaultHistory is the default path of the history file
+func DefaultHistory() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".feynman_history")
+}
+
+// balance computes the parenthesis depth of a statement
+func balance(statement string) int {
+	return strings.Count(statement, "(") - strings.Count(statement, ")")
+}
+
+// REPL runs a read eval print loop over a session
+func REPL(s *Session, h *History, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	prompt := "> "
+	lines := []string{}
+	for {
+		fmt.Fprint(out, prompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		line := scanner.Text()
+		if strings.HasSuffix(line, `\`) {
+			lines, prompt = append(lines, strings.TrimSuffix(line, `\`)), ". "
+			continue
+		}
+		lines = append(lines, line)
+		statement := strings.Join(lines, "\n")
+		if balance(statement) > 0 {
+			prompt = ". "
+			continue
+		}
+		lines, prompt = lines[:0], "> "
+		trimmed := strings.TrimSpace(statement)
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "quit" || trimmed == "exit":
+			return nil
+		case trimmed == "history":
+			for i, entry := range h.Entries {
+				fmt.Fprintf(out, "%d  %s\n", i+1, strings.ReplaceAll(entry, "\n", "\n   "))
+			}
+			continue
+		case strings.HasPrefix(trimmed, "!"):
+			i, err := strconv.Atoi(trimmed[1:])
+			if err != nil || i < 1 || i > len(h.Entries) {
+				fmt.Fprintln(out, "error: no such history entry")
+				continue
+			}
+			statement = h.Entries[i-1]
+			fmt.Fprintln(out, statement)
+		}
+		if err := h.Add(statement); err != nil {
+			return err
+		}
+		result, err := s.Execute(statement)
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+			continue
+		}
+		if result != "" {
+			fmt.Fprintln(out, result)
+		}
+	}
+}
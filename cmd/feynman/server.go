@@ -0,0 +1,194 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pointlander/feynman"
+)
+
+const (
+	// DefaultTimeout is the default request timeout
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxExpression is the default maximum expression length in bytes
+	DefaultMaxExpression = 4096
+	// DefaultMaxDepth is the default maximum integration search depth
+	DefaultMaxDepth = 8
+)
+
+// ServerOptions are the options for the server
+type ServerOptions struct {
+	// Timeout is the maximum duration of a request
+	Timeout time.Duration
+	// MaxExpression is the maximum length of an expression in bytes
+	MaxExpression int
+	// MaxDepth is the maximum integration search depth
+	MaxDepth int
+}
+
+// Request is the body of a request
+type Request struct {
+	Expression string             `json:"expression"`
+	Variables  []string           `json:"variables,omitempty"`
+	Values     map[string]float64 `json:"values,omitempty"`
+	Depth      int                `json:"depth,omitempty"`
+	Simplify   bool               `json:"simplify,omitempty"`
+}
+
+// Response is the body of a response
+type Response struct {
+	Expression string   `json:"expression,omitempty"`
+	Value      *float64 `json:"value,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Server is a http server exposing the expression engine
+type Server struct {
+	ServerOptions
+	mux *http.ServeMux
+}
+
+// requestError is an error with a http status
+type requestError struct {
+	status int
+	err    error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// NewServer creates a new server
+func NewServer(opts ServerOptions) *Server {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxExpression == 0 {
+		opts.MaxExpression = DefaultMaxExpression
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+	s := &Server{
+		ServerOptions: opts,
+		mux:           http.NewServeMux(),
+	}
+	s.handle("/parse", func(ctx context.Context, r *Request, n *feynman.Node) (*Response, error) {
+		return &Response{Expression: n.String()}, nil
+	})
+	s.handle("/derivative", func(ctx context.Context, r *Request, n *feynman.Node) (*Response, error) {
+		if len(r.Variables) == 0 {
+			return nil, &requestError{http.StatusBadRequest, errors.New("no variables")}
+		}
+		with := make(map[string]bool)
+		for _, v := range r.Variables {
+			with[v] = true
+		}
+		d := n.Derivative(with)
+		if r.Simplify {
+			d = d.Simplify()
+		}
+		return &Response{Expression: d.String()}, nil
+	})
+	s.handle("/simplify", func(ctx context.Context, r *Request, n *feynman.Node) (*Response, error) {
+		return &Response{Expression: n.Simplify().String()}, nil
+	})
+	s.handle("/calculate", func(ctx context.Context, r *Request, n *feynman.Node) (*Response, error) {
+		value, err := n.CalculateContext(ctx, r.Values)
+		if err != nil {
+			return nil, err
+		}
+		response := &Response{Text: strconv.FormatFloat(value, 'g', -1, 64)}
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			response.Value = &value
+		}
+		return response, nil
+	})
+	s.handle("/integrate", func(ctx context.Context, r *Request, n *feynman.Node) (*Response, error) {
+		depth := r.Depth
+		if depth == 0 {
+			depth = 5
+		}
+		if depth < 2 || depth > s.MaxDepth {
+			return nil, &requestError{http.StatusBadRequest, fmt.Errorf("depth must be between 2 and %d", s.MaxDepth)}
+		}
+		a, err := feynman.IntegrateContext(ctx, depth, n.String())
+		if err != nil {
+			return nil, err
+		}
+		return &Response{Expression: a.String()}, nil
+	})
+	return s
+}
+
+// handle registers an endpoint which parses the expression of a request
+func (s *Server) handle(path string, f func(ctx context.Context, r *Request, n *feynman.Node) (*Response, error)) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			s.write(w, http.StatusMethodNotAllowed, &Response{Error: "method not allowed"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.Timeout)
+		defer cancel()
+		request := Request{}
+		body := http.MaxBytesReader(w, r.Body, int64(4*s.MaxExpression+4096))
+		if err := json.NewDecoder(body).Decode(&request); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				s.write(w, http.StatusRequestEntityTooLarge, &Response{Error: "request too large"})
+				return
+			}
+			s.write(w, http.StatusBadRequest, &Response{Error: "invalid request: " + err.Error()})
+			return
+		}
+		if len(request.Expression) > s.MaxExpression {
+			s.write(w, http.StatusRequestEntityTooLarge, &Response{
+				Error: fmt.Sprintf("expression longer than %d bytes", s.MaxExpression),
+			})
+			return
+		}
+		n, err := feynman.Parse(request.Expression)
+		if err != nil {
+			s.write(w, http.StatusBadRequest, &Response{Error: "parse error"})
+			return
+		}
+		response, err := f(ctx, &request, n)
+		var re *requestError
+		switch {
+		case err == nil:
+			s.write(w, http.StatusOK, response)
+		case errors.As(err, &re):
+			s.write(w, re.status, &Response{Error: re.Error()})
+		case errors.Is(err, context.DeadlineExceeded):
+			s.write(w, http.StatusGatewayTimeout, &Response{Error: "timeout"})
+		case errors.Is(err, context.Canceled):
+			s.write(w, http.StatusServiceUnavailable, &Response{Error: "canceled"})
+		default:
+			s.write(w, http.StatusInternalServerError, &Response{Error: err.Error()})
+		}
+	})
+}
+
+// write writes a json response
+func (s *Server) write(w http.ResponseWriter, status int, response *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ServeHTTP serves a request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, server *httptest.Server, path string, body string) (int, Response) {
+	t.Helper()
+	r, err := http.Post(server.URL+path, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	response := Response{}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return r.StatusCode, response
+}
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(NewServer(ServerOptions{}))
+	defer server.Close()
+
+	tests := []struct {
+		path       string
+		body       string
+		status     int
+		expression string
+	}{
+		{"/parse", `{"expression": "1+2*x"}`, http.StatusOK, "(1 + (2 * x))"},
+		{"/derivative", `{"expression": "x^2", "variables": ["x"], "simplify": true}`, http.StatusOK, "(2 * (x^(2 - 1)))"},
+		{"/derivative", `{"expression": "x^2"}`, http.StatusBadRequest, ""},
+		{"/simplify", `{"expression": "0 + x*1"}`, http.StatusOK, "x"},
+		{"/simplify", `{"expression": "x +"}`, http.StatusBadRequest, ""},
+		{"/simplify", `{"expression": `, http.StatusBadRequest, ""},
+		{"/integrate", `{"expression": "2*x", "depth": 5}`, http.StatusOK, ""},
+		{"/integrate", `{"expression": "2*x", "depth": 100}`, http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		status, response := post(t, server, test.path, test.body)
+		if status != test.status {
+			t.Fatal(test.path, test.body, "got incorrect status", status, response.Error)
+		}
+		if test.expression != "" && response.Expression != test.expression {
+			t.Fatal(test.path, test.body, "got incorrect expression", response.Expression)
+		}
+	}
+
+	status, response := post(t, server, "/calculate", `{"expression": "x*y", "values": {"x": 2, "y": 3}}`)
+	if status != http.StatusOK || response.Value == nil || *response.Value != 6 {
+		t.Fatal("got incorrect value", status, response)
+	}
+	status, response = post(t, server, "/calculate", `{"expression": "1/0"}`)
+	if status != http.StatusOK || response.Value != nil || response.Text != "+Inf" {
+		t.Fatal("got incorrect value", status, response)
+	}
+
+	r, err := http.Get(server.URL + "/parse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatal("got incorrect status", r.StatusCode)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	server := httptest.NewServer(NewServer(ServerOptions{
+		Timeout:       50 * time.Millisecond,
+		MaxExpression: 64,
+	}))
+	defer server.Close()
+
+	expression := strings.Repeat("x+", 64) + "x"
+	status, _ := post(t, server, "/parse", `{"expression": "`+expression+`"}`)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatal("got incorrect status", status)
+	}
+	status, _ = post(t, server, "/parse", `{"expression": "`+strings.Repeat("x", 1024)+`"}`)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatal("got incorrect status", status)
+	}
+
+	start := time.Now()
+	status, response := post(t, server, "/integrate", `{"expression": "2*x*cos(x^2) + x^5*sin(x)"}`)
+	if status != http.StatusGatewayTimeout {
+		t.Fatal("got incorrect status", status, response)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatal("integration was not canceled", elapsed)
+	}
+}
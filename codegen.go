@@ -0,0 +1,246 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Function is a named expression to generate code for
+type Function struct {
+	Name       string
+	Expression *Node
+}
+
+// freeVariables returns the sorted names of the variables of the functions
+func freeVariables(functions []Function) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, f := range functions {
+		for _, name := range f.Expression.FreeVariables() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Code is a function lowered to temporaries and a result in the syntax of
+// an emitter
+type Code struct {
+	Name        string
+	Comment     string
+	Params      []string
+	Temporaries []Temporary
+	Result      string
+}
+
+// Temporary is a temporary holding a common subexpression
+type Temporary struct {
+	Name  string
+	Value string
+}
+
+// Emitter emits source code for a target language
+type Emitter interface {
+	// Identifier maps a variable to an identifier that can't collide with
+	// the keywords and functions of the language or the temporaries
+	Identifier(name string) string
+	// Terminal formats a number, constant or variable
+	Terminal(n *Node) (string, error)
+	// Operation formats an operation applied to formatted operands
+	Operation(o Operation, operands ...string) (string, error)
+	// File formats a source file of functions
+	File(functions []Code) ([]byte, error)
+}
+
+// emit formats the terms of a plan with an emitter, with the terms used more
+// than once held in temporaries
+func emit(e Emitter, p *Plan, code *Code) ([]string, error) {
+	formatted := make([]string, len(p.Terms))
+	for i, t := range p.Terms {
+		var value string
+		var err error
+		switch arity(t.Operation) {
+		case 0:
+			value, err = e.Terminal(&Node{Operation: t.Operation, Value: t.Value, Variable: t.Variable})
+		case 1:
+			if t.Left == None {
+				return nil, fmt.Errorf("missing operand")
+			}
+			value, err = e.Operation(t.Operation, formatted[t.Left])
+		default:
+			if t.Left == None || t.Right == None {
+				return nil, fmt.Errorf("missing operand")
+			}
+			value, err = e.Operation(t.Operation, formatted[t.Left], formatted[t.Right])
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.isTemporary(t, p.Uses[i]) {
+			name := "t_" + strconv.Itoa(len(code.Temporaries))
+			code.Temporaries = append(code.Temporaries, Temporary{Name: name, Value: value})
+			value = name
+		}
+		formatted[i] = value
+	}
+	return formatted, nil
+}
+
+// formatOperation substitutes the formatted operands into the format of an
+// operation
+func formatOperation(format string, operands []string) string {
+	args := make([]any, len(operands))
+	for i, v := range operands {
+		args[i] = v
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Emit generates a source file with an emitter with a function for each
+// expression taking params as arguments, or the sorted free variables of the
+// expressions if params is nil. Common subexpressions found by NewPlan are
+// held in temporaries.
+func Emit(e Emitter, params []string, functions ...Function) ([]byte, error) {
+	if params == nil {
+		params = freeVariables(functions)
+	}
+	identifiers := make([]string, len(params))
+	for i, p := range params {
+		identifiers[i] = e.Identifier(p)
+	}
+	codes := make([]Code, 0, len(functions))
+	for _, f := range functions {
+		if !gotoken.IsIdentifier(f.Name) || e.Identifier(f.Name) != f.Name {
+			return nil, fmt.Errorf("invalid function name %q", f.Name)
+		}
+		if f.Expression == nil {
+			return nil, fmt.Errorf("function %s has no expression", f.Name)
+		}
+		code := Code{
+			Name:    f.Name,
+			Comment: f.Name + " computes " + f.Expression.String(),
+			Params:  identifiers,
+		}
+		plan := NewPlan(f.Expression)
+		formatted, err := emit(e, plan, &code)
+		if err != nil {
+			return nil, err
+		}
+		code.Result = formatted[plan.Outputs[0]]
+		codes = append(codes, code)
+	}
+	return e.File(codes)
+}
+
+// GoEmitter emits go using the math package
+type GoEmitter struct {
+	// Package is the name of the package
+	Package string
+}
+
+// Identifier maps a variable to a go identifier
+func (g *GoEmitter) Identifier(name string) string {
+	if gotoken.IsKeyword(name) || name == "math" {
+		return name + "_"
+	}
+	return name
+}
+
+// Terminal formats a terminal as go
+func (g *GoEmitter) Terminal(n *Node) (string, error) {
+	switch n.Operation {
+	case OperationNumber:
+		v := n.Value
+		switch {
+		case math.IsInf(v, 1):
+			return "math.Inf(1)", nil
+		case math.IsInf(v, -1):
+			return "math.Inf(-1)", nil
+		case math.IsNaN(v):
+			return "math.NaN()", nil
+		case v < 0:
+			return "(" + strconv.FormatFloat(v, 'g', -1, 64) + ")", nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case OperationVariable:
+		return g.Identifier(n.Variable), nil
+	case OperationPI:
+		return "math.Pi", nil
+	case OperationNatural:
+		return "math.E", nil
+	}
+	return "", fmt.Errorf("unsupported operation %s", n.Operation)
+}
+
+// Operation formats an operation as go
+func (g *GoEmitter) Operation(o Operation, operands ...string) (string, error) {
+	format, ok := map[Operation]string{
+		OperationAdd:                   "(%s + %s)",
+		OperationSubtract:              "(%s - %s)",
+		OperationMultiply:              "(%s * %s)",
+		OperationDivide:                "(%s / %s)",
+		OperationModulus:               "math.Mod(%s, %s)",
+		OperationExponentiation:        "math.Pow(%s, %s)",
+		OperationNotation:              "(%s * math.Pow(10, %s))",
+		OperationNegate:                "(-%s)",
+		OperationCosine:                "math.Cos(%s)",
+		OperationSine:                  "math.Sin(%s)",
+		OperationTangent:               "math.Tan(%s)",
+		OperationSquareRoot:            "math.Sqrt(%s)",
+		OperationNaturalLogarithm:      "math.Log(%s)",
+		OperationNaturalExponentiation: "math.Exp(%s)",
+	}[o]
+	if !ok {
+		return "", fmt.Errorf("unsupported operation %s", o)
+	}
+	return formatOperation(format, operands), nil
+}
+
+// File formats a gofmt formatted go file
+func (g *GoEmitter) File(functions []Code) ([]byte, error) {
+	body := &bytes.Buffer{}
+	for _, f := range functions {
+		arguments := strings.Join(f.Params, ", ")
+		if arguments != "" {
+			arguments += " float64"
+		}
+		fmt.Fprintf(body, "\n// %s\n", f.Comment)
+		fmt.Fprintf(body, "func %s(%s) float64 {\n", f.Name, arguments)
+		for _, t := range f.Temporaries {
+			fmt.Fprintf(body, "%s := %s\n", t.Name, t.Value)
+		}
+		fmt.Fprintf(body, "return %s\n}\n", f.Result)
+	}
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "// Code generated by feynman. DO NOT EDIT.")
+	fmt.Fprintln(buffer)
+	fmt.Fprintf(buffer, "package %s\n", g.Package)
+	if bytes.Contains(body.Bytes(), []byte("math.")) {
+		fmt.Fprintln(buffer)
+		fmt.Fprintln(buffer, "import \"math\"")
+	}
+	buffer.Write(body.Bytes())
+	return format.Source(buffer.Bytes())
+}
+
+// GenerateGo generates a formatted go file in package pkg with a function
+// for each expression taking params as arguments, or the sorted free
+// variables of the expressions if params is nil. Common subexpressions are
+// hoisted into temporaries.
+func GenerateGo(pkg string, params []string, functions ...Function) ([]byte, error) {
+	return Emit(&GoEmitter{Package: pkg}, params, functions...)
+}
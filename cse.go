@@ -0,0 +1,141 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"math"
+)
+
+// Plan is an evaluation plan for expressions where structurally equal
+// subexpressions are computed once. The terms are in the order they must be
+// computed, every term is needed by an output.
+type Plan struct {
+	Terms   []Term
+	Outputs []ID
+	// Uses is the number of references to each term by other terms and the
+	// outputs
+	Uses []int
+	// Nodes is the number of nodes in the trees of the expressions
+	Nodes int
+}
+
+// NewPlan creates an evaluation plan for expressions by common subexpression
+// elimination, the expressions are not otherwise simplified
+func NewPlan(expressions ...*Node) *Plan {
+	d := NewDAG()
+	p := &Plan{}
+	var count func(n *Node)
+	count = func(n *Node) {
+		if n == nil {
+			return
+		}
+		p.Nodes++
+		for _, child := range n.children() {
+			count(child)
+		}
+	}
+	for _, n := range expressions {
+		p.Outputs = append(p.Outputs, d.add(n, false))
+		count(n)
+	}
+	// folding derivatives interns terms no output needs, which are swept
+	live := make([]bool, len(d.Terms))
+	var mark func(id ID)
+	mark = func(id ID) {
+		if id == None || live[id] {
+			return
+		}
+		live[id] = true
+		mark(d.Terms[id].Left)
+		mark(d.Terms[id].Right)
+	}
+	for _, id := range p.Outputs {
+		mark(id)
+	}
+	index := make([]ID, len(d.Terms))
+	for i, t := range d.Terms {
+		if !live[i] {
+			continue
+		}
+		index[i] = ID(len(p.Terms))
+		if t.Left != None {
+			t.Left = index[t.Left]
+		}
+		if t.Right != None {
+			t.Right = index[t.Right]
+		}
+		p.Terms = append(p.Terms, t)
+	}
+	for i, id := range p.Outputs {
+		if id != None {
+			p.Outputs[i] = index[id]
+		}
+	}
+	p.Uses = make([]int, len(p.Terms))
+	for _, t := range p.Terms {
+		if t.Left != None {
+			p.Uses[t.Left]++
+		}
+		if t.Right != None {
+			p.Uses[t.Right]++
+		}
+	}
+	for _, id := range p.Outputs {
+		if id != None {
+			p.Uses[id]++
+		}
+	}
+	return p
+}
+
+// Temporaries returns the non terminal terms used more than once, which are
+// held in temporaries by code generators
+func (p *Plan) Temporaries() []ID {
+	temporaries := []ID{}
+	for i, t := range p.Terms {
+		if p.isTemporary(t, p.Uses[i]) {
+			temporaries = append(temporaries, ID(i))
+		}
+	}
+	return temporaries
+}
+
+// isTemporary returns if a term is held in a temporary
+func (p *Plan) isTemporary(t Term, uses int) bool {
+	return t.Left != None && uses > 1
+}
+
+// Savings returns the number of nodes that aren't computed by the plan
+func (p *Plan) Savings() int {
+	return p.Nodes - len(p.Terms)
+}
+
+// Evaluate computes the outputs of the plan given the values of the
+// variables
+func (p *Plan) Evaluate(x map[string]float64) []float64 {
+	values := make([]float64, len(p.Terms))
+	value := func(id ID) float64 {
+		if id == None {
+			return 0
+		}
+		return values[id]
+	}
+	for i, t := range p.Terms {
+		if t.Operation == OperationVariable {
+			values[i] = x[t.Variable]
+		} else {
+			values[i] = calculate(t, value(t.Left), value(t.Right))
+		}
+	}
+	outputs := make([]float64, len(p.Outputs))
+	for i, id := range p.Outputs {
+		if id == None {
+			outputs[i] = math.NaN()
+			continue
+		}
+		outputs[i] = values[id]
+	}
+	return outputs
+}
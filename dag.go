@@ -0,0 +1,469 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"math"
+)
+
+// ID identifies a term of a DAG
+type ID int
+
+// None is the ID of a missing child
+const None ID = -1
+
+// Term is a hash consed expression, its children are terms of the same DAG
+type Term struct {
+	Operation Operation
+	Value     float64
+	Variable  string
+	Left      ID
+	Right     ID
+}
+
+// termKey is the key of a term, with the value as bits so that nans are equal
+type termKey struct {
+	Operation Operation
+	Value     uint64
+	Variable  string
+	Left      ID
+	Right     ID
+}
+
+// derivativeKey is the key of a memoized derivative
+type derivativeKey struct {
+	ID       ID
+	Variable string
+}
+
+// DAG is a hash consed expression graph, structurally equal subexpressions
+// are stored once as the same term so repeated differentiation doesn't grow
+// expressions exponentially like it does trees. Terms are only added, so the children of a term
+// always have smaller IDs.
+type DAG struct {
+	Terms       []Term
+	index       map[termKey]ID
+	derivatives map[derivativeKey]ID
+}
+
+// NewDAG creates a new empty DAG
+func NewDAG() *DAG {
+	return &DAG{
+		index:       make(map[termKey]ID),
+		derivatives: make(map[derivativeKey]ID),
+	}
+}
+
+// Len returns the number of terms in the DAG
+func (d *DAG) Len() int {
+	return len(d.Terms)
+}
+
+// key normalizes a term and returns it with its key
+func (t Term) key() (Term, termKey) {
+	switch {
+	case t.Operation == OperationNumber || t.Operation == OperationImaginary:
+		// -0 == 0 and all nans are equal
+		if t.Value == 0 {
+			t.Value = 0
+		} else if math.IsNaN(t.Value) {
+			t.Value = math.NaN()
+		}
+	case t.Operation == OperationPI:
+		t.Value, t.Variable = math.Pi, "pi"
+	default:
+		t.Value = 0
+	}
+	if t.Operation != OperationVariable && t.Operation != OperationPattern && t.Operation != OperationPI {
+		t.Variable = ""
+	}
+	return t, termKey{
+		Operation: t.Operation,
+		Value:     math.Float64bits(t.Value),
+		Variable:  t.Variable,
+		Left:      t.Left,
+		Right:     t.Right,
+	}
+}
+
+// intern returns the ID of a term, adding it if it isn't in the DAG
+func (d *DAG) intern(t Term) ID {
+	t, key := t.key()
+	if id, ok := d.index[key]; ok {
+		return id
+	}
+	id := ID(len(d.Terms))
+	d.Terms = append(d.Terms, t)
+	d.index[key] = id
+	return id
+}
+
+// Number returns the ID of a number
+func (d *DAG) Number(v float64) ID {
+	return d.intern(Term{Operation: OperationNumber, Value: v, Left: None, Right: None})
+}
+
+// Variable returns the ID of a variable
+func (d *DAG) Variable(name string) ID {
+	return d.intern(Term{Operation: OperationVariable, Variable: name, Left: None, Right: None})
+}
+
+// number returns the value of a term and if it is a number
+func (d *DAG) number(id ID) (float64, bool) {
+	if id == None || d.Terms[id].Operation != OperationNumber {
+		return 0, false
+	}
+	return d.Terms[id].Value, true
+}
+
+// isNumber returns if a term is the number v
+func (d *DAG) isNumber(id ID, v float64) bool {
+	value, ok := d.number(id)
+	return ok && value == v
+}
+
+// Apply returns the ID of an operation applied to terms, use None for the
+// right child of a unary operation. Operations on numbers are folded and the
+// identities of Simplify are applied locally.
+func (d *DAG) Apply(o Operation, left, right ID) ID {
+	if arity(o) == 1 {
+		right = None
+	}
+	a, aok := d.number(left)
+	b, bok := d.number(right)
+	if aok && (bok || right == None) && o != OperationNoop {
+		if v := calculate(Term{Operation: o}, a, b); !math.IsInf(v, 0) && !math.IsNaN(v) {
+			return d.Number(v)
+		}
+	}
+	zero, one := d.isNumber(left, 0), d.isNumber(left, 1)
+	switch o {
+	case OperationAdd:
+		switch {
+		case zero:
+			return right
+		case d.isNumber(right, 0):
+			return left
+		}
+	case OperationSubtract:
+		switch {
+		case d.isNumber(right, 0):
+			return left
+		case left == right:
+			return d.Number(0)
+		case zero:
+			return d.Apply(OperationNegate, right, None)
+		}
+	case OperationMultiply:
+		switch {
+		case zero || d.isNumber(right, 0):
+			return d.Number(0)
+		case one:
+			return right
+		case d.isNumber(right, 1):
+			return left
+		}
+	case OperationDivide:
+		switch {
+		case zero:
+			return d.Number(0)
+		case d.isNumber(right, 1):
+			return left
+		}
+	case OperationExponentiation:
+		switch {
+		case d.isNumber(right, 0):
+			return d.Number(1)
+		case d.isNumber(right, 1):
+			return left
+		}
+	case OperationNegate:
+		if left != None && d.Terms[left].Operation == OperationNegate {
+			return d.Terms[left].Left
+		}
+	}
+	return d.intern(Term{Operation: o, Left: left, Right: right})
+}
+
+// Add adds a tree to the DAG and returns the ID of its root
+func (d *DAG) Add(n *Node) ID {
+	return d.add(n, true)
+}
+
+// add adds a tree to the DAG, optionally without folding
+func (d *DAG) add(n *Node, fold bool) ID {
+	seen := make(map[*Node]ID)
+	var process func(n *Node) ID
+	process = func(n *Node) ID {
+		if n == nil {
+			return None
+		}
+		if id, ok := seen[n]; ok {
+			return id
+		}
+		var id ID
+		switch n.Operation {
+		case OperationDerivative:
+			// derivatives are expanded, integrals have no terms so they are NaN
+			id = d.Derivative(process(n.Left), n.Right.Variable)
+			seen[n] = id
+			return id
+		case OperationIntegral:
+			id = d.Number(math.NaN())
+			seen[n] = id
+			return id
+		}
+		switch arity(n.Operation) {
+		case 0:
+			left, right := None, None
+			if n.Operation == OperationNoop {
+				left, right = process(n.Left), process(n.Right)
+			}
+			id = d.intern(Term{
+				Operation: n.Operation,
+				Value:     n.Value,
+				Variable:  n.Variable,
+				Left:      left,
+				Right:     right,
+			})
+		case 1:
+			left := process(n.Left)
+			if fold {
+				id = d.Apply(n.Operation, left, None)
+			} else {
+				id = d.intern(Term{Operation: n.Operation, Left: left, Right: None})
+			}
+		default:
+			left, right := process(n.Left), process(n.Right)
+			if fold {
+				id = d.Apply(n.Operation, left, right)
+			} else {
+				id = d.intern(Term{Operation: n.Operation, Left: left, Right: right})
+			}
+		}
+		seen[n] = id
+		return id
+	}
+	return process(n)
+}
+
+// Node converts a term to a tree, terms that are shared in the DAG are
+// shared by pointer in the tree
+func (d *DAG) Node(id ID) *Node {
+	seen := make(map[ID]*Node)
+	var process func(id ID) *Node
+	process = func(id ID) *Node {
+		if id == None {
+			return nil
+		}
+		if n, ok := seen[id]; ok {
+			return n
+		}
+		t := d.Terms[id]
+		n := &Node{
+			Operation: t.Operation,
+			Value:     t.Value,
+			Variable:  t.Variable,
+			Left:      process(t.Left),
+			Right:     process(t.Right),
+		}
+		seen[id] = n
+		return n
+	}
+	return process(id)
+}
+
+// Size returns the number of distinct terms reachable from a term
+func (d *DAG) Size(id ID) int {
+	seen := make(map[ID]bool)
+	var process func(id ID)
+	process = func(id ID) {
+		if id == None || seen[id] {
+			return
+		}
+		seen[id] = true
+		process(d.Terms[id].Left)
+		process(d.Terms[id].Right)
+	}
+	process(id)
+	return len(seen)
+}
+
+// calculate computes a term given the values of its children
+func calculate(t Term, a, b float64) float64 {
+	switch t.Operation {
+	case OperationNumber:
+		return t.Value
+	case OperationPI:
+		return math.Pi
+	case OperationNatural:
+		return math.E
+	case OperationNegate:
+		return -a
+	case OperationAdd:
+		return a + b
+	case OperationSubtract, OperationEquation:
+		return a - b
+	case OperationMultiply:
+		return a * b
+	case OperationDivide:
+		return a / b
+	case OperationExponentiation:
+		return math.Pow(a, b)
+	case OperationCosine:
+		return math.Cos(a)
+	case OperationSine:
+		return math.Sin(a)
+	case OperationModulus:
+		return math.Mod(a, b)
+	case OperationTangent:
+		return math.Tan(a)
+	case OperationSquareRoot:
+		return math.Sqrt(a)
+	case OperationNaturalLogarithm:
+		return math.Log(a)
+	case OperationNaturalExponentiation:
+		return math.Exp(a)
+	case OperationNotation:
+		return a * math.Pow(10, b)
+	}
+	return 0
+}
+
+// Evaluate computes terms given the values of the variables, each term
+// reachable from the ids is computed once
+func (d *DAG) Evaluate(x map[string]float64, ids ...ID) []float64 {
+	values, done := make([]float64, len(d.Terms)), make([]bool, len(d.Terms))
+	var process func(id ID) float64
+	process = func(id ID) float64 {
+		if id == None {
+			return 0
+		}
+		if done[id] {
+			return values[id]
+		}
+		t := d.Terms[id]
+		if t.Operation == OperationVariable {
+			values[id] = x[t.Variable]
+		} else {
+			values[id] = calculate(t, process(t.Left), process(t.Right))
+		}
+		done[id] = true
+		return values[id]
+	}
+	results := make([]float64, len(ids))
+	for i, id := range ids {
+		results[i] = process(id)
+	}
+	return results
+}
+
+// Derivative returns the derivative of a term with respect to a variable.
+// Derivatives are memoized so shared terms are differentiated once.
+func (d *DAG) Derivative(id ID, x string) ID {
+	if id == None {
+		return None
+	}
+	key := derivativeKey{ID: id, Variable: x}
+	if a, ok := d.derivatives[key]; ok {
+		return a
+	}
+	t := d.Terms[id]
+	zero, one, two := d.Number(0), d.Number(1), d.Number(2)
+	var a ID
+	switch t.Operation {
+	case OperationNoop:
+		a = id
+	case OperationAdd, OperationSubtract, OperationEquation:
+		a = d.Apply(t.Operation, d.Derivative(t.Left, x), d.Derivative(t.Right, x))
+	case OperationMultiply:
+		a = d.Apply(OperationAdd,
+			d.Apply(OperationMultiply, t.Left, d.Derivative(t.Right, x)),
+			d.Apply(OperationMultiply, t.Right, d.Derivative(t.Left, x)))
+	case OperationDivide:
+		difference := d.Apply(OperationSubtract,
+			d.Apply(OperationMultiply, t.Right, d.Derivative(t.Left, x)),
+			d.Apply(OperationMultiply, t.Left, d.Derivative(t.Right, x)))
+		a = d.Apply(OperationDivide, difference, d.Apply(OperationExponentiation, t.Right, two))
+	case OperationModulus:
+		// a % b = a - b*trunc(a/b) and trunc(a/b) = (a - a%b)/b
+		quotient := d.Apply(OperationDivide, d.Apply(OperationSubtract, t.Left, id), t.Right)
+		a = d.Apply(OperationSubtract,
+			d.Derivative(t.Left, x),
+			d.Apply(OperationMultiply, d.Derivative(t.Right, x), quotient))
+	case OperationExponentiation:
+		// d(u^v) = v*u^(v-1)*u' + u^v*log(u)*v', the second term folds away
+		// for constant exponents
+		power := d.Apply(OperationMultiply,
+			d.Apply(OperationMultiply, t.Right,
+				d.Apply(OperationExponentiation, t.Left, d.Apply(OperationSubtract, t.Right, one))),
+			d.Derivative(t.Left, x))
+		exponent := d.Apply(OperationMultiply,
+			d.Apply(OperationMultiply, id, d.Apply(OperationNaturalLogarithm, t.Left, None)),
+			d.Derivative(t.Right, x))
+		if exponent == zero {
+			a = power
+		} else {
+			a = d.Apply(OperationAdd, power, exponent)
+		}
+	case OperationNotation:
+		ten := d.Number(10)
+		scale := d.Apply(OperationExponentiation, ten, t.Right)
+		a = d.Apply(OperationAdd,
+			d.Apply(OperationMultiply, d.Derivative(t.Left, x), scale),
+			d.Apply(OperationMultiply,
+				d.Apply(OperationMultiply, id, d.Apply(OperationNaturalLogarithm, ten, None)),
+				d.Derivative(t.Right, x)))
+	case OperationNegate:
+		a = d.Apply(OperationNegate, d.Derivative(t.Left, x), None)
+	case OperationVariable:
+		if t.Variable == x {
+			a = one
+		} else {
+			a = zero
+		}
+	case OperationNaturalExponentiation:
+		a = d.Apply(OperationMultiply, id, d.Derivative(t.Left, x))
+	case OperationNaturalLogarithm:
+		a = d.Apply(OperationDivide, d.Derivative(t.Left, x), t.Left)
+	case OperationSquareRoot:
+		a = d.Apply(OperationDivide, d.Derivative(t.Left, x), d.Apply(OperationMultiply, two, id))
+	case OperationCosine:
+		a = d.Apply(OperationNegate,
+			d.Apply(OperationMultiply, d.Apply(OperationSine, t.Left, None), d.Derivative(t.Left, x)), None)
+	case OperationSine:
+		a = d.Apply(OperationMultiply, d.Apply(OperationCosine, t.Left, None), d.Derivative(t.Left, x))
+	case OperationTangent:
+		a = d.Apply(OperationMultiply,
+			d.Apply(OperationAdd, one, d.Apply(OperationExponentiation, id, two)),
+			d.Derivative(t.Left, x))
+	default:
+		a = zero
+	}
+	d.derivatives[key] = a
+	return a
+}
+
+// NthDerivative returns the nth derivative of a term with respect to a
+// variable
+func (d *DAG) NthDerivative(id ID, x string, n int) ID {
+	for range n {
+		id = d.Derivative(id, x)
+	}
+	return id
+}
+
+// Jacobian returns the matrix of the derivatives of each term with respect
+// to each variable
+func (d *DAG) Jacobian(ids []ID, x []string) [][]ID {
+	jacobian := make([][]ID, len(ids))
+	for i, id := range ids {
+		jacobian[i] = make([]ID, len(x))
+		for j, v := range x {
+			jacobian[i][j] = d.Derivative(id, v)
+		}
+	}
+	return jacobian
+}
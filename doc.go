@@ -0,0 +1,58 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package feynman is a symbolic expression engine.
+//
+// Expressions are parsed into trees of *Node with Parse, differentiated with
+// Node.Derivative, simplified with Node.Simplify, evaluated with
+// Node.Calculate and printed with Node.String or Node.LaTeX. Trees are
+// compared with Node.Equal, hashed with Node.Hash and put in canonical form
+// with Node.Canonical. A DAG stores expressions hash consed, with memoized
+// evaluation and differentiation for nth derivatives and Jacobians.
+//
+// Node.Substitute replaces variables with trees, Node.Rename renames them
+// and Node.FreeVariables lists them; pi is a reserved constant, not a
+// variable.
+//
+// Series expands an expression in a Taylor series with exact rational
+// coefficients where possible and a remainder estimate. Limit finds one or
+// two sided limits, at finite or infinite points, resolving indeterminate
+// forms with series, dominance rules and L'Hôpital's rule.
+//
+// Antiderivatives are found by a markov model guided search with Integrate
+// and IntegrateContext, built on Markov, Source and Roots. Definite
+// integrals are computed numerically with Quad, adaptive Gauss-Kronrod
+// quadrature, and QuadResult.Check compares them with an antiderivative.
+// Expressions may contain diff(e, x), integrate(e, x) and integrate(e, x, a,
+// b), which Calculate computes numerically and Evaluate replaces with
+// derivatives, antiderivatives or, failing the search, quadrature.
+//
+// The parameters of a parsed model are fit to a Dataset with Fit, using one
+// of the Optimizer implementations, or with LevenbergMarquardt for nonlinear
+// least squares.
+//
+// Polynomials with rational coefficients are built from trees with
+// NewPolynomial, and Expand, Collect and Factor put polynomial expressions in
+// standard form. Rational functions are put over a common denominator in
+// lowest terms with Together, using the polynomial GCD, and decomposed into
+// partial fractions with Apart. Equations like x^2 - 3*x + 2 = 0 are solved
+// for a variable with Solve, exactly when they are linear or quadratic and
+// otherwise by isolating the variable, giving each Solution with the
+// conditions on the other variables under which it holds.
+//
+// Rules of the form lhs -> rhs with pattern variables like ?a are parsed
+// with ParseRule and applied by a Rewriter, DefaultRules simplify like
+// Simplify. An EGraph applies rules non destructively by equality
+// saturation and Optimize extracts the cheapest equivalent tree under a
+// CostFunction such as NodeCount, EvaluationCost or Stability.
+// SimplifyTrig applies the trigonometric identities of a TrigStrategy:
+// Pythagorean identities, parity, special values at multiples of pi and the
+// expansion or contraction of sums and doubles of angles.
+//
+// A Plan evaluates expressions with common subexpressions computed once.
+// Expressions are compiled to source code with Emit, using GoEmitter,
+// CEmitter, PythonEmitter or JavaScriptEmitter, or with GenerateGo.
+//
+// The command line interface is in cmd/feynman.
+package feynman
@@ -0,0 +1,148 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Label returns the short label of a node
+func (n *Node) Label() string {
+	switch n.Operation {
+	case OperationNoop:
+		return "???"
+	case OperationAdd:
+		return "+"
+	case OperationSubtract:
+		return "-"
+	case OperationMultiply:
+		return "*"
+	case OperationDivide:
+		return "/"
+	case OperationModulus:
+		return "%"
+	case OperationExponentiation:
+		return "^"
+	case OperationNegate:
+		return "neg"
+	case OperationVariable:
+		return n.Variable
+	case OperationPattern:
+		return "?" + n.Variable
+	case OperationImaginary:
+		return strconv.FormatFloat(n.Value, 'g', -1, 64) + "i"
+	case OperationNumber:
+		return strconv.FormatFloat(n.Value, 'g', -1, 64)
+	case OperationNotation:
+		return "e"
+	case OperationNaturalExponentiation:
+		return "exp"
+	case OperationNatural:
+		return "e"
+	case OperationPI:
+		return "pi"
+	case OperationNaturalLogarithm:
+		return "log"
+	case OperationSquareRoot:
+		return "sqrt"
+	case OperationCosine:
+		return "cos"
+	case OperationSine:
+		return "sin"
+	case OperationTangent:
+		return "tan"
+	case OperationDerivative:
+		return "diff"
+	case OperationIntegral:
+		return "integrate"
+	case OperationBounds:
+		return n.Variable
+	case OperationEquation:
+		return "="
+	}
+	return n.Operation.String()
+}
+
+// dotWriter writes the nodes and edges of trees into a graph
+type dotWriter struct {
+	w      *bufio.Writer
+	indent string
+	count  int
+	shared bool
+}
+
+// node writes a tree and returns the id of its root
+func (d *dotWriter) node(prefix string, n *Node, seen map[*Node]string) string {
+	if id, ok := seen[n]; ok && d.shared {
+		return id
+	}
+	id := prefix + strconv.Itoa(d.count)
+	d.count++
+	seen[n] = id
+	shape := "ellipse"
+	if n.Left == nil && n.Right == nil {
+		shape = "box"
+	}
+	fmt.Fprintf(d.w, "%s%s [label=%s shape=%s];\n", d.indent, id, strconv.Quote(n.Label()), shape)
+	if n.Left != nil {
+		fmt.Fprintf(d.w, "%s%s -> %s;\n", d.indent, id, d.node(prefix, n.Left, seen))
+	}
+	if n.Right != nil {
+		fmt.Fprintf(d.w, "%s%s -> %s;\n", d.indent, id, d.node(prefix, n.Right, seen))
+	}
+	return id
+}
+
+// WriteDOT writes a tree as a graphviz dot graph, if shared is true nodes
+// shared by pointer are written once to show the DAG
+func WriteDOT(w io.Writer, n *Node, shared bool) error {
+	d := &dotWriter{
+		w:      bufio.NewWriter(w),
+		indent: "\t",
+		shared: shared,
+	}
+	fmt.Fprintln(d.w, "digraph expression {")
+	fmt.Fprintln(d.w, "\tordering=out;")
+	if n != nil {
+		d.node("n", n, make(map[*Node]string))
+	}
+	fmt.Fprintln(d.w, "}")
+	return d.w.Flush()
+}
+
+// WriteRootsDOT writes the k fittest roots of a generation side by side as
+// a graphviz dot graph labeled with their fitness
+func WriteRootsDOT(w io.Writer, r Roots, k int, shared bool) error {
+	sorted := make(Roots, len(r))
+	copy(sorted, r)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Fitness < sorted[j].Fitness
+	})
+	if k < len(sorted) {
+		sorted = sorted[:k]
+	}
+	d := &dotWriter{
+		w:      bufio.NewWriter(w),
+		indent: "\t\t",
+		shared: shared,
+	}
+	fmt.Fprintln(d.w, "digraph roots {")
+	fmt.Fprintln(d.w, "\tordering=out;")
+	for i, root := range sorted {
+		fmt.Fprintf(d.w, "\tsubgraph cluster_%d {\n", i)
+		label := fmt.Sprintf("#%d index=%d fitness=%s", i, root.Index, strconv.FormatFloat(root.Fitness, 'g', 6, 64))
+		fmt.Fprintf(d.w, "\t\tlabel=%s;\n", strconv.Quote(label))
+		if root.Root != nil {
+			d.node("r"+strconv.Itoa(i)+"_", root.Root, make(map[*Node]string))
+		}
+		fmt.Fprintln(d.w, "\t}")
+	}
+	fmt.Fprintln(d.w, "}")
+	return d.w.Flush()
+}
@@ -0,0 +1,534 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultSaturationIterations is the default maximum number of rounds of
+	// rule application
+	DefaultSaturationIterations = 8
+	// DefaultSaturationNodes is the default maximum number of e-nodes
+	DefaultSaturationNodes = 1 << 13
+)
+
+// EGraph is an e-graph: a set of equivalence classes of terms whose
+// children are classes, so that rewrites add equivalent forms instead of
+// replacing them. The IDs of the terms are class IDs.
+type EGraph struct {
+	parents []ID
+	classes map[ID][]Term
+	memo    map[termKey]ID
+	nodes   int
+}
+
+// NewEGraph creates a new empty e-graph
+func NewEGraph() *EGraph {
+	return &EGraph{
+		classes: make(map[ID][]Term),
+		memo:    make(map[termKey]ID),
+	}
+}
+
+// Find returns the canonical ID of a class
+func (g *EGraph) Find(id ID) ID {
+	for g.parents[id] != id {
+		g.parents[id] = g.parents[g.parents[id]]
+		id = g.parents[id]
+	}
+	return id
+}
+
+// canonical returns a term with canonical children
+func (g *EGraph) canonical(t Term) Term {
+	if t.Left != None {
+		t.Left = g.Find(t.Left)
+	}
+	if t.Right != None {
+		t.Right = g.Find(t.Right)
+	}
+	return t
+}
+
+// add adds a term and returns its class
+func (g *EGraph) add(t Term) ID {
+	t, key := g.canonical(t).key()
+	if id, ok := g.memo[key]; ok {
+		return g.Find(id)
+	}
+	id := ID(len(g.parents))
+	g.parents = append(g.parents, id)
+	g.classes[id] = []Term{t}
+	g.memo[key] = id
+	g.nodes++
+	return id
+}
+
+// Add adds a tree and returns the class of its root
+func (g *EGraph) Add(n *Node) ID {
+	if n == nil {
+		return None
+	}
+	t := Term{
+		Operation: n.Operation,
+		Value:     n.Value,
+		Variable:  n.Variable,
+		Left:      None,
+		Right:     None,
+	}
+	switch children := n.children(); len(children) {
+	case 1:
+		t.Left = g.Add(children[0])
+	case 2:
+		t.Left, t.Right = g.Add(children[0]), g.Add(children[1])
+	}
+	return g.add(t)
+}
+
+// Union merges two classes and returns if they were different
+func (g *EGraph) Union(a, b ID) bool {
+	a, b = g.Find(a), g.Find(b)
+	if a == b {
+		return false
+	}
+	if len(g.classes[a]) < len(g.classes[b]) {
+		a, b = b, a
+	}
+	g.parents[b] = a
+	g.classes[a] = append(g.classes[a], g.classes[b]...)
+	delete(g.classes, b)
+	return true
+}
+
+// ids returns the sorted IDs of the classes
+func (g *EGraph) ids() []ID {
+	ids := make([]ID, 0, len(g.classes))
+	for id := range g.classes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Rebuild restores the congruence invariant after unions: terms with equal
+// operations and equivalent children are in the same class
+func (g *EGraph) Rebuild() {
+	for changed := true; changed; {
+		changed = false
+		g.memo = make(map[termKey]ID)
+		for _, id := range g.ids() {
+			for _, t := range g.classes[id] {
+				_, key := g.canonical(t).key()
+				if other, ok := g.memo[key]; ok {
+					if g.Union(other, id) {
+						changed = true
+					}
+					continue
+				}
+				g.memo[key] = id
+			}
+		}
+	}
+	g.nodes = 0
+	for _, id := range g.ids() {
+		terms := g.classes[id]
+		seen := make(map[termKey]bool, len(terms))
+		unique := terms[:0]
+		for _, t := range terms {
+			t, key := g.canonical(t).key()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			unique = append(unique, t)
+			g.memo[key] = id
+		}
+		g.classes[id] = unique
+		g.nodes += len(unique)
+	}
+}
+
+// Classes returns the number of classes
+func (g *EGraph) Classes() int {
+	return len(g.classes)
+}
+
+// Nodes returns the number of e-nodes
+func (g *EGraph) Nodes() int {
+	return g.nodes
+}
+
+// match returns the extensions of the bindings under which a pattern
+// matches a class
+func (g *EGraph) match(pattern *Node, id ID, b map[string]ID) []map[string]ID {
+	id = g.Find(id)
+	if pattern.Operation == OperationPattern {
+		if bound, ok := b[pattern.Variable]; ok {
+			if g.Find(bound) == id {
+				return []map[string]ID{b}
+			}
+			return nil
+		}
+		c := make(map[string]ID, len(b)+1)
+		for k, v := range b {
+			c[k] = v
+		}
+		c[pattern.Variable] = id
+		return []map[string]ID{c}
+	}
+	matches := []map[string]ID{}
+	p, _ := Term{Operation: pattern.Operation, Value: pattern.Value, Variable: pattern.Variable}.key()
+	children := pattern.children()
+	for _, t := range g.classes[id] {
+		if t.Operation != p.Operation || t.Variable != p.Variable ||
+			(pattern.hasValue() && t.Value != p.Value) {
+			continue
+		}
+		partial := []map[string]ID{b}
+		for i, child := range children {
+			c := t.Left
+			if i == 1 {
+				c = t.Right
+			}
+			if child == nil || c == None {
+				partial = nil
+				break
+			}
+			next := []map[string]ID{}
+			for _, a := range partial {
+				next = append(next, g.match(child, c, a)...)
+			}
+			partial = next
+		}
+		matches = append(matches, partial...)
+	}
+	return matches
+}
+
+// instantiate adds a tree with pattern variables bound to classes
+func (g *EGraph) instantiate(n *Node, b map[string]ID) ID {
+	if n.Operation == OperationPattern {
+		return b[n.Variable]
+	}
+	t := Term{
+		Operation: n.Operation,
+		Value:     n.Value,
+		Variable:  n.Variable,
+		Left:      None,
+		Right:     None,
+	}
+	switch children := n.children(); len(children) {
+	case 1:
+		t.Left = g.instantiate(children[0], b)
+	case 2:
+		t.Left, t.Right = g.instantiate(children[0], b), g.instantiate(children[1], b)
+	}
+	return g.add(t)
+}
+
+// complete returns if every pattern variable is bound to a tree
+func complete(b Bindings) bool {
+	for _, n := range b {
+		if n == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// SaturationOptions are options for equality saturation
+type SaturationOptions struct {
+	// Iterations is the maximum number of rounds of rule application
+	Iterations int
+	// Nodes is the maximum number of e-nodes
+	Nodes int
+}
+
+// Saturate applies rules to every class non destructively until no rule
+// adds anything new or a limit is reached, and returns if the e-graph is
+// saturated. Conditions and transforms of rules are given the cheapest trees
+// by node count of the bound classes.
+func (g *EGraph) Saturate(rules []*Rule, opts SaturationOptions) bool {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultSaturationIterations
+	}
+	if opts.Nodes <= 0 {
+		opts.Nodes = DefaultSaturationNodes
+	}
+	type Application struct {
+		Rule     *Rule
+		Class    ID
+		Bindings map[string]ID
+	}
+	for range opts.Iterations {
+		ids := g.ids()
+		matches := []Application{}
+		for _, rule := range rules {
+			for _, id := range ids {
+				for _, b := range g.match(rule.LHS, id, map[string]ID{}) {
+					matches = append(matches, Application{Rule: rule, Class: id, Bindings: b})
+				}
+			}
+		}
+		var extracted map[ID]*Node
+		changed := false
+		for _, m := range matches {
+			if g.Nodes() > opts.Nodes {
+				g.Rebuild()
+				return false
+			}
+			var b Bindings
+			if m.Rule.Condition != nil || m.Rule.Transform != nil {
+				if extracted == nil {
+					extracted = g.extractAll(NodeCount)
+				}
+				b = make(Bindings, len(m.Bindings))
+				for k, v := range m.Bindings {
+					b[k] = extracted[g.Find(v)]
+				}
+				if !complete(b) {
+					continue
+				}
+				if m.Rule.Condition != nil && !m.Rule.Condition(b) {
+					continue
+				}
+			}
+			var id ID
+			if m.Rule.Transform != nil {
+				a := m.Rule.Transform(b)
+				if a == nil {
+					continue
+				}
+				id = g.Add(a)
+			} else {
+				id = g.instantiate(m.Rule.RHS, m.Bindings)
+			}
+			if g.Union(m.Class, id) {
+				changed = true
+			}
+		}
+		nodes := g.Nodes()
+		g.Rebuild()
+		if !changed && g.Nodes() == nodes {
+			return true
+		}
+	}
+	return false
+}
+
+// CostFunction returns the cost of a node given the costs of its children,
+// the children of the node aren't set. The cost must be greater than the
+// cost of each child.
+type CostFunction func(n *Node, children []float64) float64
+
+// sum returns the sum of costs
+func sum(costs []float64) float64 {
+	total := 0.0
+	for _, v := range costs {
+		total += v
+	}
+	return total
+}
+
+// NodeCount is the number of nodes of a tree
+func NodeCount(n *Node, children []float64) float64 {
+	return 1 + sum(children)
+}
+
+// EvaluationCost is an estimate of the time to calculate a tree
+func EvaluationCost(n *Node, children []float64) float64 {
+	cost := 1.0
+	switch n.Operation {
+	case OperationMultiply:
+		cost = 2
+	case OperationDivide, OperationModulus:
+		cost = 8
+	case OperationSquareRoot:
+		cost = 16
+	case OperationExponentiation, OperationCosine, OperationSine, OperationTangent,
+		OperationNaturalExponentiation, OperationNaturalLogarithm, OperationNotation:
+		cost = 32
+	}
+	return cost + sum(children)
+}
+
+// Stability is a heuristic cost of the numerical error of a tree:
+// subtraction can cancel, division and powers can overflow and the node
+// count breaks ties
+func Stability(n *Node, children []float64) float64 {
+	cost := 1.0
+	switch n.Operation {
+	case OperationSubtract, OperationModulus:
+		cost = 16
+	case OperationDivide, OperationExponentiation, OperationNaturalExponentiation,
+		OperationNaturalLogarithm, OperationTangent:
+		cost = 4
+	}
+	return cost + sum(children)
+}
+
+// costs computes the cheapest term and its cost of each class
+func (g *EGraph) costs(cost CostFunction) (map[ID]Term, map[ID]float64) {
+	best, costs := make(map[ID]Term), make(map[ID]float64)
+	for changed := true; changed; {
+		changed = false
+		for _, id := range g.ids() {
+			for _, t := range g.classes[id] {
+				children := []float64{}
+				ok := true
+				for _, c := range []ID{t.Left, t.Right} {
+					if c == None {
+						continue
+					}
+					v, found := costs[g.Find(c)]
+					if !found {
+						ok = false
+						break
+					}
+					children = append(children, v)
+				}
+				if !ok {
+					continue
+				}
+				v := cost(&Node{Operation: t.Operation, Value: t.Value, Variable: t.Variable}, children)
+				if old, found := costs[id]; math.IsNaN(v) || (found && v >= old) {
+					continue
+				}
+				best[id], costs[id] = t, v
+				changed = true
+			}
+		}
+	}
+	return best, costs
+}
+
+// extractAll extracts the cheapest tree of each class
+func (g *EGraph) extractAll(cost CostFunction) map[ID]*Node {
+	best, _ := g.costs(cost)
+	extracted := make(map[ID]*Node, len(best))
+	var process func(id ID) *Node
+	process = func(id ID) *Node {
+		if id == None {
+			return nil
+		}
+		id = g.Find(id)
+		if n, ok := extracted[id]; ok {
+			return n
+		}
+		t, ok := best[id]
+		if !ok {
+			return nil
+		}
+		n := &Node{
+			Operation: t.Operation,
+			Value:     t.Value,
+			Variable:  t.Variable,
+			Left:      process(t.Left),
+			Right:     process(t.Right),
+		}
+		extracted[id] = n
+		return n
+	}
+	for _, id := range g.ids() {
+		process(id)
+	}
+	return extracted
+}
+
+// Extract returns the cheapest tree of a class and its cost, subtrees of
+// the tree are shared by pointer
+func (g *EGraph) Extract(id ID, cost CostFunction) (*Node, float64) {
+	id = g.Find(id)
+	_, costs := g.costs(cost)
+	return g.extractAll(cost)[id], costs[id]
+}
+
+// AlgebraicRules returns rewrites of algebraic identities for equality
+// saturation, including the commutative and associative laws which can't be
+// used by a Rewriter. Divisors other than the number 0 are assumed to be
+// nonzero.
+func AlgebraicRules() []*Rule {
+	nonzero := func(names ...string) func(b Bindings) bool {
+		return func(b Bindings) bool {
+			for _, name := range names {
+				if n := b[name]; n.Operation == OperationNumber && n.Value == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	fold := func(rule string) *Rule {
+		a := mustParseRule(rule, nil)
+		a.Transform = func(b Bindings) *Node {
+			x, y := b["a"], b["b"]
+			if x.Operation != OperationNumber || (y != nil && y.Operation != OperationNumber) {
+				return nil
+			}
+			right := 0.0
+			if y != nil {
+				right = y.Value
+			}
+			v := calculate(Term{Operation: a.LHS.Operation}, x.Value, right)
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				return nil
+			}
+			return &Node{Operation: OperationNumber, Value: v}
+		}
+		return a
+	}
+	return []*Rule{
+		mustParseRule("?a + ?b -> ?b + ?a", nil),
+		mustParseRule("?a * ?b -> ?b * ?a", nil),
+		mustParseRule("(?a + ?b) + ?c -> ?a + (?b + ?c)", nil),
+		mustParseRule("(?a * ?b) * ?c -> ?a * (?b * ?c)", nil),
+		mustParseRule("?a * (?b + ?c) -> ?a*?b + ?a*?c", nil),
+		mustParseRule("?a*?b + ?a*?c -> ?a * (?b + ?c)", nil),
+		mustParseRule("?a - ?b -> ?a + -?b", nil),
+		mustParseRule("?a + -?b -> ?a - ?b", nil),
+		mustParseRule("-(-?a) -> ?a", nil),
+		mustParseRule("?a * -?b -> -(?a * ?b)", nil),
+		mustParseRule("?a + 0 -> ?a", nil),
+		mustParseRule("?a - ?a -> 0", nil),
+		mustParseRule("?a * 0 -> 0", nil),
+		mustParseRule("?a * 1 -> ?a", nil),
+		mustParseRule("-0 -> 0", nil),
+		mustParseRule("?a / 1 -> ?a", nil),
+		mustParseRule("?a / ?a -> 1", nonzero("a")),
+		mustParseRule("0 / ?a -> 0", nonzero("a")),
+		mustParseRule("(?a * ?b) / ?b -> ?a", nonzero("b")),
+		mustParseRule("?a / (?a * ?b) -> 1 / ?b", nonzero("a", "b")),
+		mustParseRule("(?a * ?b) / (?a * ?c) -> ?b / ?c", nonzero("a", "c")),
+		mustParseRule("?a / ?b / ?c -> ?a / (?b * ?c)", nil),
+		mustParseRule("?a^2 -> ?a * ?a", nil),
+		mustParseRule("?a * ?a -> ?a^2", nil),
+		mustParseRule("?a^1 -> ?a", nil),
+		mustParseRule("?a^0 -> 1", nil),
+		fold("?a + ?b -> 0"),
+		fold("?a - ?b -> 0"),
+		fold("?a * ?b -> 0"),
+		fold("?a / ?b -> 0"),
+		fold("?a ^ ?b -> 0"),
+		fold("-?a -> 0"),
+	}
+}
+
+// Optimize finds the cheapest tree equivalent to a tree under rules by
+// equality saturation, the rules are AlgebraicRules if nil
+func Optimize(n *Node, rules []*Rule, cost CostFunction, opts SaturationOptions) *Node {
+	if rules == nil {
+		rules = AlgebraicRules()
+	}
+	g := NewEGraph()
+	id := g.Add(n)
+	g.Saturate(rules, opts)
+	a, _ := g.Extract(id, cost)
+	return a
+}
@@ -0,0 +1,329 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// floatLiteral formats a finite number as a floating point literal, so that
+// integer arithmetic isn't used in languages that have it
+func floatLiteral(v float64) string {
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	if v < 0 {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// reserved returns a set of names
+func reserved(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// cReserved are the c99 keywords and the math.h functions used
+var cReserved = reserved("auto", "break", "case", "char", "const", "continue",
+	"default", "do", "double", "else", "enum", "extern", "float", "for", "goto",
+	"if", "inline", "int", "long", "register", "restrict", "return", "short",
+	"signed", "sizeof", "static", "struct", "switch", "typedef", "union",
+	"unsigned", "void", "volatile", "while", "cos", "sin", "tan", "sqrt", "log",
+	"exp", "pow", "fmod")
+
+// CEmitter emits c99 using math.h
+type CEmitter struct{}
+
+// Identifier maps a variable to a c identifier
+func (c *CEmitter) Identifier(name string) string {
+	if cReserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// Terminal formats a terminal as c
+func (c *CEmitter) Terminal(n *Node) (string, error) {
+	switch n.Operation {
+	case OperationNumber:
+		v := n.Value
+		switch {
+		case math.IsInf(v, 1):
+			return "INFINITY", nil
+		case math.IsInf(v, -1):
+			return "(-INFINITY)", nil
+		case math.IsNaN(v):
+			return "NAN", nil
+		}
+		return floatLiteral(v), nil
+	case OperationVariable:
+		return c.Identifier(n.Variable), nil
+	case OperationPI:
+		return floatLiteral(math.Pi), nil
+	case OperationNatural:
+		return floatLiteral(math.E), nil
+	}
+	return "", fmt.Errorf("unsupported operation %s", n.Operation)
+}
+
+// Operation formats an operation as c, modulus is fmod which truncates like
+// math.Mod
+func (c *CEmitter) Operation(o Operation, operands ...string) (string, error) {
+	format, ok := map[Operation]string{
+		OperationAdd:                   "(%s + %s)",
+		OperationSubtract:              "(%s - %s)",
+		OperationMultiply:              "(%s * %s)",
+		OperationDivide:                "(%s / %s)",
+		OperationModulus:               "fmod(%s, %s)",
+		OperationExponentiation:        "pow(%s, %s)",
+		OperationNotation:              "(%s * pow(10.0, %s))",
+		OperationNegate:                "(-%s)",
+		OperationCosine:                "cos(%s)",
+		OperationSine:                  "sin(%s)",
+		OperationTangent:               "tan(%s)",
+		OperationSquareRoot:            "sqrt(%s)",
+		OperationNaturalLogarithm:      "log(%s)",
+		OperationNaturalExponentiation: "exp(%s)",
+	}[o]
+	if !ok {
+		return "", fmt.Errorf("unsupported operation %s", o)
+	}
+	return formatOperation(format, operands), nil
+}
+
+// File formats a c file
+func (c *CEmitter) File(functions []Code) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "/* Code generated by feynman. DO NOT EDIT. */")
+	fmt.Fprintln(buffer)
+	fmt.Fprintln(buffer, "#include <math.h>")
+	for _, f := range functions {
+		arguments := "void"
+		if len(f.Params) > 0 {
+			arguments = "double " + strings.Join(f.Params, ", double ")
+		}
+		fmt.Fprintf(buffer, "\n/* %s */\n", f.Comment)
+		fmt.Fprintf(buffer, "double %s(%s) {\n", f.Name, arguments)
+		for _, t := range f.Temporaries {
+			fmt.Fprintf(buffer, "\tconst double %s = %s;\n", t.Name, t.Value)
+		}
+		fmt.Fprintf(buffer, "\treturn %s;\n}\n", f.Result)
+	}
+	return buffer.Bytes(), nil
+}
+
+// pythonReserved are the python keywords and the module names used
+var pythonReserved = reserved("False", "None", "True", "and", "as", "assert",
+	"async", "await", "break", "class", "continue", "def", "del", "elif", "else",
+	"except", "finally", "for", "from", "global", "if", "import", "in", "is",
+	"lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while",
+	"with", "yield", "math", "np")
+
+// PythonEmitter emits python using the math module, or numpy so that the
+// functions apply to arrays elementwise. Modulus is fmod which truncates like
+// math.Mod, unlike the % operator which floors. With the math module domain
+// errors and division by zero raise exceptions instead of returning nan or inf.
+type PythonEmitter struct {
+	// NumPy selects numpy instead of the math module
+	NumPy bool
+}
+
+// module returns the name of the math module
+func (p *PythonEmitter) module() string {
+	if p.NumPy {
+		return "np"
+	}
+	return "math"
+}
+
+// Identifier maps a variable to a python identifier
+func (p *PythonEmitter) Identifier(name string) string {
+	if pythonReserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// Terminal formats a terminal as python
+func (p *PythonEmitter) Terminal(n *Node) (string, error) {
+	m := p.module()
+	switch n.Operation {
+	case OperationNumber:
+		v := n.Value
+		switch {
+		case math.IsInf(v, 1):
+			return m + ".inf", nil
+		case math.IsInf(v, -1):
+			return "(-" + m + ".inf)", nil
+		case math.IsNaN(v):
+			return m + ".nan", nil
+		}
+		return floatLiteral(v), nil
+	case OperationVariable:
+		return p.Identifier(n.Variable), nil
+	case OperationPI:
+		return m + ".pi", nil
+	case OperationNatural:
+		return m + ".e", nil
+	}
+	return "", fmt.Errorf("unsupported operation %s", n.Operation)
+}
+
+// Operation formats an operation as python
+func (p *PythonEmitter) Operation(o Operation, operands ...string) (string, error) {
+	m := p.module()
+	power := "math.pow(%s, %s)"
+	if p.NumPy {
+		power = "np.power(%s, %s)"
+	}
+	format, ok := map[Operation]string{
+		OperationAdd:                   "(%s + %s)",
+		OperationSubtract:              "(%s - %s)",
+		OperationMultiply:              "(%s * %s)",
+		OperationDivide:                "(%s / %s)",
+		OperationModulus:               m + ".fmod(%s, %s)",
+		OperationExponentiation:        power,
+		OperationNotation:              "(%s * 10.0 ** %s)",
+		OperationNegate:                "(-%s)",
+		OperationCosine:                m + ".cos(%s)",
+		OperationSine:                  m + ".sin(%s)",
+		OperationTangent:               m + ".tan(%s)",
+		OperationSquareRoot:            m + ".sqrt(%s)",
+		OperationNaturalLogarithm:      m + ".log(%s)",
+		OperationNaturalExponentiation: m + ".exp(%s)",
+	}[o]
+	if !ok {
+		return "", fmt.Errorf("unsupported operation %s", o)
+	}
+	return formatOperation(format, operands), nil
+}
+
+// File formats a python file
+func (p *PythonEmitter) File(functions []Code) ([]byte, error) {
+	body := &bytes.Buffer{}
+	for _, f := range functions {
+		fmt.Fprintf(body, "\n\ndef %s(%s):\n", f.Name, strings.Join(f.Params, ", "))
+		fmt.Fprintf(body, "    \"\"\"%s\"\"\"\n", f.Comment)
+		for _, t := range f.Temporaries {
+			fmt.Fprintf(body, "    %s = %s\n", t.Name, t.Value)
+		}
+		fmt.Fprintf(body, "    return %s\n", f.Result)
+	}
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "# Code generated by feynman. DO NOT EDIT.")
+	if bytes.Contains(body.Bytes(), []byte(p.module()+".")) {
+		fmt.Fprintln(buffer)
+		if p.NumPy {
+			fmt.Fprintln(buffer, "import numpy as np")
+		} else {
+			fmt.Fprintln(buffer, "import math")
+		}
+	}
+	buffer.Write(body.Bytes())
+	return buffer.Bytes(), nil
+}
+
+// javaScriptReserved are the javascript reserved words and the globals used
+var javaScriptReserved = reserved("await", "break", "case", "catch", "class",
+	"const", "continue", "debugger", "default", "delete", "do", "else", "enum",
+	"export", "extends", "false", "finally", "for", "function", "if",
+	"implements", "import", "in", "instanceof", "interface", "let", "new",
+	"null", "package", "private", "protected", "public", "return", "static",
+	"super", "switch", "this", "throw", "true", "try", "typeof", "var", "void",
+	"while", "with", "yield", "arguments", "eval", "undefined", "Infinity",
+	"NaN", "Math")
+
+// JavaScriptEmitter emits javascript using Math, modulus is the % operator
+// which truncates like math.Mod
+type JavaScriptEmitter struct {
+	// Module exports the functions from an es module
+	Module bool
+}
+
+// Identifier maps a variable to a javascript identifier
+func (j *JavaScriptEmitter) Identifier(name string) string {
+	if javaScriptReserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// Terminal formats a terminal as javascript
+func (j *JavaScriptEmitter) Terminal(n *Node) (string, error) {
+	switch n.Operation {
+	case OperationNumber:
+		v := n.Value
+		switch {
+		case math.IsInf(v, 1):
+			return "Infinity", nil
+		case math.IsInf(v, -1):
+			return "(-Infinity)", nil
+		case math.IsNaN(v):
+			return "NaN", nil
+		case v < 0:
+			return "(" + strconv.FormatFloat(v, 'g', -1, 64) + ")", nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case OperationVariable:
+		return j.Identifier(n.Variable), nil
+	case OperationPI:
+		return "Math.PI", nil
+	case OperationNatural:
+		return "Math.E", nil
+	}
+	return "", fmt.Errorf("unsupported operation %s", n.Operation)
+}
+
+// Operation formats an operation as javascript
+func (j *JavaScriptEmitter) Operation(o Operation, operands ...string) (string, error) {
+	format, ok := map[Operation]string{
+		OperationAdd:                   "(%s + %s)",
+		OperationSubtract:              "(%s - %s)",
+		OperationMultiply:              "(%s * %s)",
+		OperationDivide:                "(%s / %s)",
+		OperationModulus:               "(%s %% %s)",
+		OperationExponentiation:        "(%s ** %s)",
+		OperationNotation:              "(%s * 10 ** %s)",
+		OperationNegate:                "(-%s)",
+		OperationCosine:                "Math.cos(%s)",
+		OperationSine:                  "Math.sin(%s)",
+		OperationTangent:               "Math.tan(%s)",
+		OperationSquareRoot:            "Math.sqrt(%s)",
+		OperationNaturalLogarithm:      "Math.log(%s)",
+		OperationNaturalExponentiation: "Math.exp(%s)",
+	}[o]
+	if !ok {
+		return "", fmt.Errorf("unsupported operation %s", o)
+	}
+	return formatOperation(format, operands), nil
+}
+
+// File formats a javascript file
+func (j *JavaScriptEmitter) File(functions []Code) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "// Code generated by feynman. DO NOT EDIT.")
+	export := ""
+	if j.Module {
+		export = "export "
+	}
+	for _, f := range functions {
+		fmt.Fprintf(buffer, "\n// %s\n", f.Comment)
+		fmt.Fprintf(buffer, "%sfunction %s(%s) {\n", export, f.Name, strings.Join(f.Params, ", "))
+		for _, t := range f.Temporaries {
+			fmt.Fprintf(buffer, "  const %s = %s;\n", t.Name, t.Value)
+		}
+		fmt.Fprintf(buffer, "  return %s;\n}\n", f.Result)
+	}
+	return buffer.Bytes(), nil
+}
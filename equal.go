@@ -0,0 +1,214 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+)
+
+// children returns the children of a node used by its operation, sampled
+// trees may carry an unused right child
+func (n *Node) children() []*Node {
+	switch arity(n.Operation) {
+	case 0:
+		if n.Operation == OperationNoop {
+			return []*Node{n.Left, n.Right}
+		}
+		return nil
+	case 1:
+		return []*Node{n.Left}
+	}
+	return []*Node{n.Left, n.Right}
+}
+
+// hasValue returns if the value of a node is part of the expression
+func (n *Node) hasValue() bool {
+	return n.Operation == OperationNumber || n.Operation == OperationImaginary
+}
+
+// hasVariable returns if the variable of a node is part of the expression
+func (n *Node) hasVariable() bool {
+	return n.Operation == OperationVariable || n.Operation == OperationPattern || n.Operation == OperationBounds
+}
+
+// Equal returns if two trees are the same expression, the search samples are
+// ignored
+func (n *Node) Equal(b *Node) bool {
+	if n == b {
+		return true
+	}
+	if n == nil || b == nil || n.Operation != b.Operation {
+		return false
+	}
+	switch {
+	case n.hasValue():
+		if n.Value != b.Value && !(math.IsNaN(n.Value) && math.IsNaN(b.Value)) {
+			return false
+		}
+	case n.hasVariable():
+		if n.Variable != b.Variable {
+			return false
+		}
+	}
+	x, y := n.children(), b.children()
+	for i := range x {
+		if !x[i].Equal(y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a stable 64 bit fnv hash of the structure of a tree, equal
+// trees have equal hashes
+func (n *Node) Hash() uint64 {
+	h := fnv.New64a()
+	var buffer [8]byte
+	var process func(n *Node)
+	process = func(n *Node) {
+		if n == nil {
+			h.Write([]byte{0xFF})
+			return
+		}
+		h.Write([]byte{byte(n.Operation)})
+		switch {
+		case n.hasValue():
+			v := n.Value
+			// -0 == 0 and all nans are equal
+			if v == 0 {
+				v = 0
+			} else if math.IsNaN(v) {
+				v = math.NaN()
+			}
+			binary.LittleEndian.PutUint64(buffer[:], math.Float64bits(v))
+			h.Write(buffer[:])
+		case n.hasVariable():
+			binary.LittleEndian.PutUint64(buffer[:], uint64(len(n.Variable)))
+			h.Write(buffer[:])
+			h.Write([]byte(n.Variable))
+		}
+		for _, child := range n.children() {
+			process(child)
+		}
+	}
+	process(n)
+	return h.Sum64()
+}
+
+// compare is a total order of trees, returning -1, 0 or 1
+func compare(a, b *Node) int {
+	switch {
+	case a == b:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.Operation != b.Operation:
+		if a.Operation < b.Operation {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case a.hasValue():
+		x, y := a.Value, b.Value
+		switch {
+		case math.IsNaN(x) && math.IsNaN(y):
+		case math.IsNaN(x) || x < y:
+			return -1
+		case math.IsNaN(y) || x > y:
+			return 1
+		}
+	case a.hasVariable():
+		if c := strings.Compare(a.Variable, b.Variable); c != 0 {
+			return c
+		}
+	}
+	x, y := a.children(), b.children()
+	for i := range x {
+		if c := compare(x[i], y[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Canonical returns a copy of a tree in canonical form without the search
+// samples: chains of the commutative operations + and * are flattened and
+// rebuilt left associated with their operands sorted, so expressions that
+// only differ in the order of their operands are Equal
+func (n *Node) Canonical() *Node {
+	var process func(n *Node) *Node
+	process = func(n *Node) *Node {
+		if n == nil {
+			return nil
+		}
+		if n.Operation == OperationAdd || n.Operation == OperationMultiply {
+			operands := []*Node{}
+			var flatten func(a *Node)
+			flatten = func(a *Node) {
+				if a != nil && a.Operation == n.Operation {
+					flatten(a.Left)
+					flatten(a.Right)
+					return
+				}
+				operands = append(operands, process(a))
+			}
+			flatten(n)
+			sort.SliceStable(operands, func(i, j int) bool {
+				return compare(operands[i], operands[j]) < 0
+			})
+			a := operands[0]
+			for _, operand := range operands[1:] {
+				a = &Node{
+					Operation: n.Operation,
+					Left:      a,
+					Right:     operand,
+				}
+			}
+			return a
+		}
+		// terminals like pi carry their value even though it isn't part of
+		// the expression
+		a := &Node{
+			Operation: n.Operation,
+			Value:     n.Value,
+			Variable:  n.Variable,
+		}
+		switch children := n.children(); len(children) {
+		case 1:
+			a.Left = process(children[0])
+		case 2:
+			a.Left, a.Right = process(children[0]), process(children[1])
+		}
+		return a
+	}
+	return process(n)
+}
+
+// Unique returns the roots with the first of each set of roots that are
+// Equal in canonical form
+func (r Roots) Unique() Roots {
+	seen := make(map[uint64][]*Node)
+	unique := make(Roots, 0, len(r))
+outer:
+	for _, root := range r {
+		canonical := root.Root.Canonical()
+		hash := canonical.Hash()
+		for _, b := range seen[hash] {
+			if b.Equal(canonical) {
+				continue outer
+			}
+		}
+		seen[hash] = append(seen[hash], canonical)
+		unique = append(unique, root)
+	}
+	return unique
+}
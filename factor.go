@@ -0,0 +1,335 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"math/big"
+)
+
+// maxFactorSearch is the largest number of candidate factors tried by one
+// search of Kronecker's method or one search for the combinations of the
+// factors of a Kronecker substitution
+const maxFactorSearch = 1 << 16
+
+// univariateFactor is a factor of a univariate polynomial raised to a power
+type univariateFactor struct {
+	base     univariate
+	exponent int
+}
+
+// normalized returns the univariate polynomial scaled to have integer
+// coefficients without a common factor and a positive leading coefficient
+func (u univariate) normalized() univariate {
+	v, _ := newUnivariate(u.polynomial("x").normalized(), "x")
+	return v
+}
+
+// derivative returns the derivative of a univariate polynomial
+func (u univariate) derivative() univariate {
+	if len(u) < 2 {
+		return univariate{}
+	}
+	a := make(univariate, len(u)-1)
+	for i := range a {
+		a[i] = new(big.Rat).Mul(u[i+1], big.NewRat(int64(i+1), 1))
+	}
+	return a.trim()
+}
+
+// gcd returns the greatest common divisor of univariate polynomials
+func (u univariate) gcd(v univariate) univariate {
+	a, _ := newUnivariate(GCD(u.polynomial("x"), v.polynomial("x")), "x")
+	return a
+}
+
+// squareFree returns the square free parts of a univariate polynomial by
+// Yun's algorithm, the part at index i has multiplicity i + 1
+func (u univariate) squareFree() []univariate {
+	parts := []univariate{}
+	a := u.gcd(u.derivative())
+	b, _ := u.divide(a)
+	c, _ := u.derivative().divide(a)
+	d := c.sub(b.derivative())
+	for len(b) > 1 {
+		a = b.gcd(d)
+		b, _ = b.divide(a)
+		c, _ = d.divide(a)
+		d = c.sub(b.derivative())
+		parts = append(parts, a)
+	}
+	return parts
+}
+
+// linear returns the linear factors of a square free primitive polynomial
+// with integer coefficients from its rational roots, and the remaining
+// factor. It is partial if the coefficients are too large to search.
+func (u univariate) linear() ([]univariate, univariate, bool) {
+	factors := []univariate{}
+	limit := big.NewInt(maxRootSearch)
+	for len(u) > 2 {
+		last, first := u[0].Num(), u[len(u)-1].Num()
+		if new(big.Int).Abs(last).Cmp(limit) > 0 || new(big.Int).Abs(first).Cmp(limit) > 0 {
+			return factors, u, true
+		}
+		found := false
+	search:
+		for _, numerator := range divisors(last) {
+			for _, denominator := range divisors(first) {
+				for _, sign := range []int64{1, -1} {
+					r := new(big.Rat).SetFrac(new(big.Int).Mul(numerator, big.NewInt(sign)), denominator)
+					if evaluate(u, r).Sign() != 0 {
+						continue
+					}
+					linear := univariate{new(big.Rat).Neg(new(big.Rat).SetInt(r.Num())), new(big.Rat).SetInt(r.Denom())}
+					factors = append(factors, linear)
+					u, _ = u.divide(linear)
+					found = true
+					break search
+				}
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return factors, u, false
+}
+
+// kronecker returns a factor of degree m of a primitive polynomial with
+// integer coefficients and no rational roots, by interpolating the divisors
+// of its values at m + 1 points. It is partial if there are too many
+// candidates to search.
+func (u univariate) kronecker(m int) (univariate, bool, bool) {
+	points := make([]*big.Rat, m+1)
+	choices := make([][]*big.Int, m+1)
+	candidates := 1
+	for i := range points {
+		// 0, 1, -1, 2, -2, ...
+		a := int64((i + 1) / 2)
+		if i%2 == 0 {
+			a = -a
+		}
+		points[i] = big.NewRat(a, 1)
+		v := evaluate(u, points[i])
+		if new(big.Int).Abs(v.Num()).Cmp(big.NewInt(maxRootSearch)) > 0 {
+			return nil, false, true
+		}
+		for _, d := range divisors(v.Num()) {
+			choices[i] = append(choices[i], d)
+			if i > 0 {
+				// the sign of the factor is fixed by its value at the first point
+				choices[i] = append(choices[i], new(big.Int).Neg(d))
+			}
+		}
+		if candidates *= len(choices[i]); candidates > maxFactorSearch {
+			return nil, false, true
+		}
+	}
+	// the lagrange basis polynomials of the points
+	basis := make([]univariate, len(points))
+	for i := range points {
+		basis[i] = univariate{big.NewRat(1, 1)}
+		for j := range points {
+			if i == j {
+				continue
+			}
+			scale := new(big.Rat).Inv(new(big.Rat).Sub(points[i], points[j]))
+			basis[i] = basis[i].mul(univariate{new(big.Rat).Neg(new(big.Rat).Mul(points[j], scale)), scale})
+		}
+	}
+	index := make([]int, len(points))
+	for {
+		g := make(univariate, m+1)
+		for k := range g {
+			g[k] = new(big.Rat)
+			for i, b := range basis {
+				if k < len(b) {
+					g[k].Add(g[k], new(big.Rat).Mul(b[k], new(big.Rat).SetInt(choices[i][index[i]])))
+				}
+			}
+		}
+		integer := g[m].Sign() != 0
+		for _, c := range g {
+			integer = integer && c.IsInt()
+		}
+		if integer {
+			if _, r := u.divide(g); len(r) == 0 {
+				return g.normalized(), true, false
+			}
+		}
+		i := 0
+		for ; i < len(index); i++ {
+			if index[i]++; index[i] < len(choices[i]) {
+				break
+			}
+			index[i] = 0
+		}
+		if i == len(index) {
+			return nil, false, false
+		}
+	}
+}
+
+// factor factors a univariate polynomial into irreducible factors with
+// integer coefficients, ignoring its content. It is partial if a factor may
+// still be reducible because a search exceeded its limits.
+func (u univariate) factor() ([]univariateFactor, bool) {
+	factors, partial := []univariateFactor{}, false
+	u = u.normalized()
+	zeros := 0
+	for zeros < len(u) && u[zeros].Sign() == 0 {
+		zeros++
+	}
+	if zeros > 0 {
+		factors = append(factors, univariateFactor{base: univariate{new(big.Rat), big.NewRat(1, 1)}, exponent: zeros})
+		u = u[zeros:]
+	}
+	for i, part := range u.squareFree() {
+		part = part.normalized()
+		if len(part) < 2 {
+			continue
+		}
+		linear, rest, incomplete := part.linear()
+		partial = partial || incomplete
+		for _, l := range linear {
+			factors = append(factors, univariateFactor{base: l, exponent: i + 1})
+		}
+		for m := 2; 2*m < len(rest); {
+			g, found, incomplete := rest.kronecker(m)
+			if incomplete {
+				partial = true
+				break
+			}
+			if !found {
+				m++
+				continue
+			}
+			factors = append(factors, univariateFactor{base: g, exponent: i + 1})
+			rest, _ = rest.divide(g)
+			rest = rest.normalized()
+		}
+		if len(rest) > 1 {
+			factors = append(factors, univariateFactor{base: rest, exponent: i + 1})
+		}
+	}
+	return factors, partial
+}
+
+// substitution is a Kronecker substitution, which maps the variables of a
+// polynomial of degree less than base in each variable to powers of t
+type substitution struct {
+	variables []string
+	base      int
+}
+
+// image returns the univariate image of a polynomial under the substitution
+func (s substitution) image(p *Polynomial) univariate {
+	u := univariate{}
+	for _, m := range p.terms {
+		k, weight := 0, 1
+		for _, name := range s.variables {
+			k += m.Powers[name] * weight
+			weight *= s.base
+		}
+		for len(u) <= k {
+			u = append(u, new(big.Rat))
+		}
+		u[k] = new(big.Rat).Add(u[k], m.Coefficient)
+	}
+	return u.trim()
+}
+
+// preimage returns the polynomial with the image u
+func (s substitution) preimage(u univariate) *Polynomial {
+	p := &Polynomial{terms: make(map[string]Monomial)}
+	for k, c := range u {
+		if c.Sign() == 0 {
+			continue
+		}
+		powers := make(map[string]int)
+		for _, name := range s.variables {
+			if power := k % s.base; power > 0 {
+				powers[name] = power
+			}
+			k /= s.base
+		}
+		p.add(Monomial{Coefficient: c, Powers: powers})
+	}
+	return p
+}
+
+// factorMultivariate factors a primitive multivariate polynomial without a
+// common monomial into irreducible factors. Products of the factors of its
+// Kronecker substitution are mapped back and tried as divisors. It is
+// partial if there are too many products to try.
+func factorMultivariate(p *Polynomial) ([]FactorPower, bool) {
+	s := substitution{variables: p.Variables()}
+	for _, name := range s.variables {
+		s.base = max(s.base, p.Degree(name)+1)
+	}
+	factors := []FactorPower{}
+	for {
+		images, _ := s.image(p).factor()
+		candidates := []univariate{}
+		for _, f := range images {
+			for range f.exponent {
+				candidates = append(candidates, f.base)
+			}
+		}
+		var divisor *Polynomial
+		tried := 0
+		// a factor or its cofactor is a product of at most half of the factors
+		for size := 1; divisor == nil && 2*size <= len(candidates); size++ {
+			index := make([]int, size)
+			for i := range index {
+				index[i] = i
+			}
+			for divisor == nil {
+				if tried++; tried > maxFactorSearch {
+					return append(factors, FactorPower{Base: p, Exponent: 1}), true
+				}
+				g := univariate{big.NewRat(1, 1)}
+				for _, i := range index {
+					g = g.mul(candidates[i])
+				}
+				h := s.preimage(g).normalized()
+				if _, ok := h.Constant(); !ok {
+					if _, ok := p.Quo(h); ok {
+						divisor = h
+						break
+					}
+				}
+				// the next combination of size indexes
+				i := size - 1
+				for i >= 0 && index[i] == len(candidates)-size+i {
+					i--
+				}
+				if i < 0 {
+					break
+				}
+				index[i]++
+				for j := i + 1; j < size; j++ {
+					index[j] = index[j-1] + 1
+				}
+			}
+		}
+		if divisor == nil {
+			return append(factors, FactorPower{Base: p, Exponent: 1}), false
+		}
+		exponent := 0
+		for {
+			q, ok := p.Quo(divisor)
+			if !ok {
+				break
+			}
+			p = q
+			exponent++
+		}
+		factors = append(factors, FactorPower{Base: divisor, Exponent: exponent})
+		if _, ok := p.Constant(); ok {
+			return factors, false
+		}
+	}
+}
@@ -2,16 +2,33 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package feynman
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
 	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
+var update = flag.Bool("update", false, "update the golden files")
+
 func TestCalculate(t *testing.T) {
 	expression := "(1--3)+2*(3+-4)"
-	calc := &Calculator[uint32]{Buffer: expression}
+	calc := &calculator[uint32]{Buffer: expression}
 	err := calc.Init()
 	if err != nil {
 		t.Fatal(err)
@@ -28,7 +45,7 @@ func TestCalculate(t *testing.T) {
 
 func TestSin(t *testing.T) {
 	expression := "sin(pi)"
-	calc := &Calculator[uint32]{Buffer: expression}
+	calc := &calculator[uint32]{Buffer: expression}
 	err := calc.Init()
 	if err != nil {
 		t.Fatal(err)
@@ -46,7 +63,7 @@ func TestSin(t *testing.T) {
 
 func TestCos(t *testing.T) {
 	expression := "cos(pi)"
-	calc := &Calculator[uint32]{Buffer: expression}
+	calc := &calculator[uint32]{Buffer: expression}
 	err := calc.Init()
 	if err != nil {
 		t.Fatal(err)
@@ -64,7 +81,7 @@ func TestCos(t *testing.T) {
 
 func TestString(t *testing.T) {
 	expression := "(((1 - -(3)) / 3) + (2 * (3 + -(4))))"
-	calc := &Calculator[uint32]{Buffer: expression}
+	calc := &calculator[uint32]{Buffer: expression}
 	err := calc.Init()
 	if err != nil {
 		t.Fatal(err)
@@ -80,7 +97,7 @@ func TestString(t *testing.T) {
 
 func TestDerivative(t *testing.T) {
 	expression := "x^2"
-	calc := &Calculator[uint32]{Buffer: expression}
+	calc := &calculator[uint32]{Buffer: expression}
 	err := calc.Init()
 	if err != nil {
 		t.Fatal(err)
@@ -93,6 +110,40 @@ func TestDerivative(t *testing.T) {
 	t.Log(da.String())
 }
 
+func TestDerivativeModulus(t *testing.T) {
+	for _, expression := range []string{"x % 3", "7 % x", "x^2 % x", "y % 2"} {
+		a, err := Parse(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		da := a.Derivative(map[string]bool{"x": true})
+		f := func(x float64) float64 {
+			return a.Calculate(map[string]float64{"x": x, "y": 5})
+		}
+		x := 2.5
+		if v, expected := da.Calculate(map[string]float64{"x": x, "y": 5}), (f(x+1e-6)-f(x-1e-6))/2e-6; math.Abs(v-expected) > 1e-6 {
+			t.Fatal("incorrect derivative", expression, v, expected)
+		}
+
+		// the derivative agrees with the derivative of a DAG
+		d := NewDAG()
+		root := d.Add(a)
+		in := map[string]float64{"x": x, "y": 5}
+		if v, expected := da.Calculate(in), d.Evaluate(in, d.Derivative(root, "x"))[0]; math.Abs(v-expected) > 1e-9 {
+			t.Fatal("incorrect derivative", expression, v, expected)
+		}
+	}
+
+	// a constant modulus has a zero derivative which simplifies away
+	a, err := Parse("x^2 + y % 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if da := a.Derivative(map[string]bool{"x": true}).Simplify(); strings.Contains(da.String(), "-(0)") || strings.Contains(da.String(), "%") {
+		t.Fatal("incorrect simplification", da.String())
+	}
+}
+
 func TestSource(t *testing.T) {
 	rng := rand.New(rand.NewSource(1))
 	s := NewSource()
@@ -115,7 +166,7 @@ func TestNewMode(t *testing.T) {
 	}
 	for _, e := range expression {
 		t.Log(e)
-		calc := &Calculator[uint32]{Buffer: e}
+		calc := &calculator[uint32]{Buffer: e}
 		err := calc.Init()
 		if err != nil {
 			panic(err)
@@ -139,3 +190,1654 @@ func TestNewMode(t *testing.T) {
 		t.Log("done")
 	}
 }
+
+func TestFit(t *testing.T) {
+	expression := "a*x + b"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	data := Dataset{}
+	for i := 0; i < 8; i++ {
+		x := float64(i) / 4
+		data = append(data, map[string]float64{"x": x, "y": 2*x + 1})
+	}
+	result, err := Fit(calc.Tree(), []string{"a", "b"}, data, FitOptions{
+		LearningRate: .01,
+		Iterations:   32 * 1024,
+		Tolerance:    1e-6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged {
+		t.Fatal("fit did not converge", result.Loss)
+	}
+	if math.Abs(result.Values["a"]-2) > 1e-3 || math.Abs(result.Values["b"]-1) > 1e-3 {
+		t.Fatal("got incorrect values", result.Values)
+	}
+
+	// the default tolerance stops the fit at the optimum
+	result, err = Fit(calc.Tree(), []string{"a", "b"}, data, FitOptions{
+		Optimizer: &LBFGS{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged || result.Iterations == DefaultIterations {
+		t.Fatal("fit did not converge with the default tolerance", result.Iterations, result.Loss)
+	}
+	if math.Abs(result.Values["a"]-2) > 1e-6 || math.Abs(result.Values["b"]-1) > 1e-6 {
+		t.Fatal("got incorrect values", result.Values)
+	}
+	result, err = Fit(calc.Tree(), []string{"a", "b"}, data, FitOptions{
+		Initial: map[string]float64{"a": 2, "b": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged || result.Iterations != 0 {
+		t.Fatal("fit did not converge at the optimum", result.Iterations, result.Loss)
+	}
+}
+
+func TestOptimizers(t *testing.T) {
+	expression := "a*x^2 + b"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	data := Dataset{}
+	for i := 0; i < 8; i++ {
+		x := float64(i) / 4
+		data = append(data, map[string]float64{"x": x, "y": 3*x*x - 1})
+	}
+	optimizers := map[string]Optimizer{
+		"descent":  &GradientDescent{Rate: 1, LineSearch: true},
+		"momentum": &Momentum{Rate: .01, Beta: .9},
+		"adam":     NewAdam(.01),
+		"lbfgs":    &LBFGS{},
+	}
+	for name, optimizer := range optimizers {
+		result, err := Fit(calc.Tree(), []string{"a", "b"}, data, FitOptions{
+			Iterations: 32 * 1024,
+			Tolerance:  1e-6,
+			Optimizer:  optimizer,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(name, result.Iterations, result.Loss)
+		if math.Abs(result.Values["a"]-3) > 1e-3 || math.Abs(result.Values["b"]+1) > 1e-3 {
+			t.Fatal(name, "got incorrect values", result.Values)
+		}
+	}
+}
+
+func TestOptimizersWithoutReset(t *testing.T) {
+	// the optimizers allocate their state for the parameters they step
+	objective := func(x []float64) (float64, []float64) {
+		l, g := 0.0, make([]float64, len(x))
+		for i, v := range x {
+			l += v * v
+			g[i] = 2 * v
+		}
+		return l, g
+	}
+	optimizers := map[string]Optimizer{
+		"descent":  &GradientDescent{Rate: .1},
+		"momentum": &Momentum{Rate: .1, Beta: .9},
+		"adam":     NewAdam(.1),
+		"lbfgs":    &LBFGS{},
+	}
+	for name, optimizer := range optimizers {
+		for _, n := range []int{2, 3} {
+			x := make([]float64, n)
+			for i := range x {
+				x[i] = 1
+			}
+			l, g := objective(x)
+			optimizer.Step(objective, x, l, g)
+			if next, _ := objective(x); next >= l {
+				t.Fatal(name, "the loss did not decrease", n, l, next)
+			}
+		}
+	}
+}
+
+func TestLevenbergMarquardt(t *testing.T) {
+	expression := "a*x + b"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	noise := []float64{.1, -.2, .05, .15, -.1, 0, -.05, .2}
+	data := Dataset{}
+	sx, sy, sxx, sxy := 0.0, 0.0, 0.0, 0.0
+	for i, e := range noise {
+		x := float64(i)
+		y := 2*x + 1 + e
+		data = append(data, map[string]float64{"x": x, "y": y})
+		sx, sy, sxx, sxy = sx+x, sy+y, sxx+x*x, sxy+x*y
+	}
+	n := float64(len(noise))
+	slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	intercept := (sy - slope*sx) / n
+	residual := Residual(calc.Tree(), "y")
+	result, err := LevenbergMarquardt([]*Node{residual}, []string{"a", "b"}, data, LeastSquaresOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged {
+		t.Fatal("did not converge")
+	}
+	if math.Abs(result.Values["a"]-slope) > 1e-9 || math.Abs(result.Values["b"]-intercept) > 1e-9 {
+		t.Fatal("got incorrect values", result.Values, slope, intercept)
+	}
+	variance := result.SumSquares / (n - 2)
+	se := math.Sqrt(variance * n / (n*sxx - sx*sx))
+	if math.Abs(result.StandardErrors["a"]-se) > 1e-9 {
+		t.Fatal("got incorrect standard error", result.StandardErrors["a"], se)
+	}
+
+	// starting at the least squares solution is converged
+	result, err = LevenbergMarquardt([]*Node{residual}, []string{"a", "b"}, data, LeastSquaresOptions{
+		Initial: map[string]float64{"a": slope, "b": intercept},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged || math.Abs(result.Values["a"]-slope) > 1e-9 {
+		t.Fatal("did not converge at the solution", result.Converged, result.Iterations, result.Values)
+	}
+
+	// a perfect fit is converged without iterating
+	exact := Dataset{}
+	for i := 0; i < 4; i++ {
+		exact = append(exact, map[string]float64{"x": float64(i), "y": 2 * float64(i)})
+	}
+	line, err := Parse("a*x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = LevenbergMarquardt([]*Node{Residual(line, "y")}, []string{"a"}, exact, LeastSquaresOptions{
+		Initial: map[string]float64{"a": 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged || result.Iterations != 0 || result.Values["a"] != 2 {
+		t.Fatal("did not converge at a perfect fit", result.Converged, result.Iterations, result.Values)
+	}
+
+	// a parameter that doesn't change the residual is at a stationary point
+	stationary, err := Parse("0*a + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = LevenbergMarquardt([]*Node{Residual(stationary, "y")}, []string{"a"}, data, LeastSquaresOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Converged || result.Iterations >= DefaultLeastSquaresIterations {
+		t.Fatal("expected a stationary point", result.Converged, result.Iterations)
+	}
+}
+
+func TestLaTeX(t *testing.T) {
+	expression := "(x1 - (x + 1))/x2^2"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	latex := calc.Tree().LaTeX()
+	if latex != `\frac{x_{1} - \left(x + 1\right)}{{x_{2}}^{2}}` {
+		t.Fatal("got incorrect latex", latex)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	expression := "(x^2 + sin(pi))/-(3 % y)"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	a := calc.Tree()
+	a = &Node{
+		Operation: OperationAdd,
+		Left:      a,
+		Right: &Node{
+			Operation: OperationNotation,
+			Left:      &Node{Operation: OperationImaginary, Value: 2},
+			Right:     &Node{Operation: OperationNumber, Value: math.Inf(1)},
+		},
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Node{}
+	if err := json.Unmarshal(data, b); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() || b.Right.Operation != OperationNotation || b.Right.Left.Operation != OperationImaginary {
+		t.Fatal("round trip failed", a, b)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	c := NewSource().Sample(4, State{}, rng)
+	data, err = json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sample") {
+		t.Fatal("samples should be excluded by default")
+	}
+	data, err = MarshalNode(c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Node{}
+	if err := json.Unmarshal(data, d); err != nil {
+		t.Fatal(err)
+	}
+	if d.OperationSample != c.OperationSample {
+		t.Fatal("samples were not included")
+	}
+
+	if err := json.Unmarshal([]byte(`{"operation": "unknown"}`), d); err == nil {
+		t.Fatal("expected an error for an unknown operation")
+	}
+	if err := json.Unmarshal([]byte(`{"operation": "add", "children": [{"operation": "number"}]}`), d); err == nil {
+		t.Fatal("expected an error for a missing child")
+	}
+}
+
+func TestDOT(t *testing.T) {
+	expression := "x*sin(x^2)"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	a := calc.Tree().Derivative(map[string]bool{"x": true})
+	tree, dag := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := WriteDOT(tree, a, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDOT(dag, a, true); err != nil {
+		t.Fatal(err)
+	}
+	nodes := func(s string) int {
+		return strings.Count(s, "[label=")
+	}
+	edges := func(s string) int {
+		return strings.Count(s, "->")
+	}
+	if nodes(tree.String()) != edges(tree.String())+1 {
+		t.Fatal("tree should have one less edge than nodes")
+	}
+	if nodes(dag.String()) >= nodes(tree.String()) || edges(dag.String()) < nodes(dag.String()) {
+		t.Fatal("dag should share nodes", nodes(dag.String()), nodes(tree.String()))
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	r := NewSource().Samples(3, rng)
+	for i := range r {
+		r[i].Fitness = float64(len(r) - i)
+	}
+	roots := &bytes.Buffer{}
+	if err := WriteRootsDOT(roots, r, 3, false); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(roots.String(), "subgraph cluster_") != 3 || !strings.Contains(roots.String(), "fitness=1\"") {
+		t.Fatal("got incorrect roots", roots.String())
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	expression := "(x3*sin(x*x1))/x4 + x2*x^3*cos(x*x1) - x^x2 + x3%2"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	a := calc.Tree()
+	functions := []Function{{Name: "Model", Expression: a}}
+	for _, v := range []string{"x1", "x2"} {
+		functions = append(functions, Function{
+			Name:       "D" + v,
+			Expression: a.Derivative(map[string]bool{v: true}).Simplify(),
+		})
+	}
+	source, err := GenerateGo("main", nil, functions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(source, []byte("func Dx1(x, x1, x2, x3, x4 float64) float64 {")) ||
+		!bytes.Contains(source, []byte("t_0 := ")) {
+		t.Fatal("got incorrect source", string(source))
+	}
+
+	inputs := []map[string]float64{
+		{"x": 3, "x1": .5, "x2": .25, "x3": 2, "x4": 7},
+		{"x": .1, "x1": 1.5, "x2": 2, "x3": -1, "x4": .3},
+	}
+	main := &bytes.Buffer{}
+	fmt.Fprintln(main, "package main\n\nimport \"fmt\"\n\nfunc main() {")
+	for _, in := range inputs {
+		for _, f := range functions {
+			fmt.Fprintf(main, "\tfmt.Println(%s(%v, %v, %v, %v, %v))\n", f.Name, in["x"], in["x1"], in["x2"], in["x3"], in["x4"])
+		}
+	}
+	fmt.Fprintln(main, "}")
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"go.mod":       []byte("module generated\n\ngo 1.24\n"),
+		"generated.go": source,
+		"main.go":      main.Bytes(),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	command := exec.Command("go", "run", ".")
+	command.Dir = dir
+	output, err := command.CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(output))
+	}
+	lines := strings.Fields(string(output))
+	i := 0
+	for _, in := range inputs {
+		for _, f := range functions {
+			got, err := strconv.ParseFloat(lines[i], 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := f.Expression.Calculate(in); got != want {
+				t.Fatal(f.Name, "got", got, "want", want)
+			}
+			i++
+		}
+	}
+
+	// the gradients agree with the derivatives of a DAG
+	d := NewDAG()
+	root := d.Add(a)
+	for _, in := range inputs {
+		for i, v := range []string{"x1", "x2"} {
+			got, want := functions[i+1].Expression.Calculate(in), d.Evaluate(in, d.Derivative(root, v))[0]
+			if math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+				t.Fatal("D"+v, "got", got, "want", want)
+			}
+		}
+	}
+}
+
+func TestEmit(t *testing.T) {
+	expression := "(x3*sin(x*x1))/x4 + x2*x^3*cos(x*x1) - x^x2 + x3%2 + pi"
+	calc := &calculator[uint32]{Buffer: expression}
+	err := calc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	a := calc.Tree()
+	x, in := &Node{Operation: OperationVariable, Variable: "x"}, &Node{Operation: OperationVariable, Variable: "in"}
+	functions := []Function{
+		{Name: "Model", Expression: a},
+		{Name: "Dx1", Expression: a.Derivative(map[string]bool{"x1": true}).Simplify()},
+		{Name: "Functions", Expression: &Node{
+			Operation: OperationAdd,
+			Left: &Node{
+				Operation: OperationMultiply,
+				Left:      &Node{Operation: OperationSquareRoot, Left: x},
+				Right:     &Node{Operation: OperationNaturalLogarithm, Left: in},
+			},
+			Right: &Node{
+				Operation: OperationSubtract,
+				Left:      &Node{Operation: OperationNaturalExponentiation, Left: &Node{Operation: OperationTangent, Left: x}},
+				Right: &Node{
+					Operation: OperationNotation,
+					Left:      &Node{Operation: OperationNumber, Value: -1.5},
+					Right:     &Node{Operation: OperationNumber, Value: 2},
+				},
+			},
+		}},
+	}
+	emitters := []struct {
+		name    string
+		emitter Emitter
+	}{
+		{"go", &GoEmitter{Package: "generated"}},
+		{"c", &CEmitter{}},
+		{"py", &PythonEmitter{}},
+		{"numpy.py", &PythonEmitter{NumPy: true}},
+		{"js", &JavaScriptEmitter{}},
+	}
+	for _, e := range emitters {
+		t.Run(e.name, func(t *testing.T) {
+			source, err := Emit(e.emitter, nil, functions...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "emit."+e.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, source, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(source, expected) {
+				t.Fatalf("got\n%s\nexpected\n%s", source, expected)
+			}
+		})
+	}
+
+	if _, err := Emit(&CEmitter{}, nil, Function{Name: "double", Expression: x}); err == nil {
+		t.Fatal("expected an error for a reserved function name")
+	}
+	if _, err := Emit(&JavaScriptEmitter{}, nil, Function{Name: "f", Expression: &Node{Operation: OperationImaginary}}); err == nil {
+		t.Fatal("expected an error for an unsupported operation")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	parse := func(expression string) *Node {
+		a, err := Parse(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	a, b := parse("x*cos(y) + 2^x"), parse("x*cos(y) + 2^x")
+	if !a.Equal(b) || a.Hash() != b.Hash() {
+		t.Fatal("expected equal trees to be equal")
+	}
+	b.OperationSample[0], b.ValueSample[0][0] = 1, 1
+	if !a.Equal(b) || a.Hash() != b.Hash() {
+		t.Fatal("expected the samples to be ignored")
+	}
+	for _, expression := range []string{"x*cos(y) + 2^y", "x*sin(y) + 2^x", "x*cos(y) - 2^x", "x*cos(y) + 3^x"} {
+		c := parse(expression)
+		if a.Equal(c) || a.Hash() == c.Hash() {
+			t.Fatal("expected different trees to differ", expression)
+		}
+	}
+
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"x + y", "y + x", true},
+		{"2*x*y", "y*(x*2)", true},
+		{"a + b*c + d", "d + c*b + a", true},
+		{"cos(x*y) + 1", "1 + cos(y*x)", true},
+		{"x - y", "y - x", false},
+		{"x / y", "y / x", false},
+		{"x + y*z", "(x + y)*z", false},
+	}
+	for _, c := range cases {
+		x, y := parse(c.a).Canonical(), parse(c.b).Canonical()
+		if x.Equal(y) != c.equal || (x.Hash() == y.Hash()) != c.equal {
+			t.Fatal("incorrect canonical form", c.a, c.b, x, y)
+		}
+	}
+	canonical := parse("a + b*c + d").Canonical()
+	if !canonical.Canonical().Equal(canonical) {
+		t.Fatal("canonical form is not stable", canonical)
+	}
+	values := map[string]float64{"x": 1, "y": 2}
+	for _, expression := range []string{"pi", "2*pi + x", "x*y - 3 + cos(pi*x)"} {
+		a := parse(expression)
+		if v, expected := a.Canonical().Calculate(values), a.Calculate(values); v != expected {
+			t.Fatal("canonical form changes the value", expression, v, expected)
+		}
+	}
+
+	r := Roots{
+		{Root: parse("x + y"), Index: 0},
+		{Root: parse("y + x"), Index: 1},
+		{Root: parse("x * y"), Index: 2},
+		{Root: parse("x + y"), Index: 3},
+	}
+	unique := r.Unique()
+	if len(unique) != 2 || unique[0].Index != 0 || unique[1].Index != 2 {
+		t.Fatal("incorrect unique roots", unique)
+	}
+}
+
+func TestDAG(t *testing.T) {
+	a, err := Parse("sin(x*y)*cos(x) + x^3/y + pi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDAG()
+	id := d.Add(a)
+	if d.Add(a) != id {
+		t.Fatal("expected the same tree to have the same id")
+	}
+	b, err := Parse("(x + y)*(x + y)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := d.Add(b); d.Terms[e].Left != d.Terms[e].Right {
+		t.Fatal("expected equal subtrees to be shared")
+	}
+	if d.Add(&Node{Operation: OperationAdd, Left: &Node{Operation: OperationNumber, Value: 2}, Right: &Node{Operation: OperationNumber, Value: 3}}) != d.Number(5) {
+		t.Fatal("expected numbers to be folded")
+	}
+
+	inputs := []map[string]float64{
+		{"x": .5, "y": 2},
+		{"x": -1.25, "y": .75},
+	}
+	dx, dy := a.Derivative(map[string]bool{"x": true}), a.Derivative(map[string]bool{"y": true})
+	jacobian := d.Jacobian([]ID{id}, []string{"x", "y"})
+	for _, in := range inputs {
+		values := d.Evaluate(in, id, jacobian[0][0], jacobian[0][1])
+		expected := []float64{a.Calculate(in), dx.Calculate(in), dy.Calculate(in)}
+		for i := range values {
+			if math.Abs(values[i]-expected[i]) > 1e-9 {
+				t.Fatal("incorrect value", i, values[i], expected[i])
+			}
+		}
+		if v := d.Node(jacobian[0][0]).Calculate(in); math.Abs(v-expected[1]) > 1e-9 {
+			t.Fatal("incorrect tree", v, expected[1])
+		}
+	}
+
+	c, err := Parse("sin(x*y)*cos(x) + x^3/y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewDAG()
+	id = e.Add(c)
+	for n := 1; n <= 12; n++ {
+		nth := e.NthDerivative(id, "x", n)
+		if size := e.Size(nth); size > 20*(n+1)*(n+1) {
+			t.Fatal("derivative is too large", n, size)
+		}
+		if n == 2 {
+			d2 := c.Derivative(map[string]bool{"x": true}).Derivative(map[string]bool{"x": true})
+			for _, in := range inputs {
+				if v, expected := e.Evaluate(in, nth)[0], d2.Calculate(in); math.Abs(v-expected) > 1e-9 {
+					t.Fatal("incorrect second derivative", v, expected)
+				}
+			}
+		}
+	}
+	if e.NthDerivative(id, "x", 12) != e.NthDerivative(e.Add(c), "x", 12) {
+		t.Fatal("expected derivatives to be memoized")
+	}
+	x := e.Variable("x")
+	power := e.Apply(OperationExponentiation, x, x)
+	for _, in := range inputs[:1] {
+		if v, expected := e.Evaluate(in, e.Derivative(power, "x"))[0], math.Pow(in["x"], in["x"])*(math.Log(in["x"])+1); math.Abs(v-expected) > 1e-9 {
+			t.Fatal("incorrect derivative of x^x", v, expected)
+		}
+	}
+	modulus := e.Apply(OperationModulus, e.Apply(OperationMultiply, x, e.Variable("y")), x)
+	in := map[string]float64{"x": 1.7, "y": 2.3}
+	f := func(h float64) float64 {
+		return math.Mod((in["x"]+h)*in["y"], in["x"]+h)
+	}
+	if v, expected := e.Evaluate(in, e.Derivative(modulus, "x"))[0], (f(1e-6)-f(-1e-6))/2e-6; math.Abs(v-expected) > 1e-6 {
+		t.Fatal("incorrect derivative of modulus", v, expected)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	a, err := Parse("sin(x*y)*cos(x) + x^3/y + (x*y)%3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dx, dy := a.Derivative(map[string]bool{"x": true}), a.Derivative(map[string]bool{"y": true})
+	expressions := []*Node{a, dx, dy}
+	p := NewPlan(expressions...)
+	if p.Savings() <= 0 || p.Nodes-p.Savings() != len(p.Terms) {
+		t.Fatal("expected savings", p.Nodes, len(p.Terms))
+	}
+	for _, id := range p.Temporaries() {
+		if p.Uses[id] < 2 || p.Terms[id].Left == None {
+			t.Fatal("incorrect temporary", p.Terms[id])
+		}
+	}
+	single := NewPlan(a)
+	temporaries := single.Temporaries()
+	if single.Nodes != 19 || len(single.Terms) != 12 || len(temporaries) != 1 ||
+		single.Terms[temporaries[0]].Operation != OperationMultiply {
+		t.Fatal("incorrect plan", single.Nodes, len(single.Terms), temporaries)
+	}
+	for _, in := range []map[string]float64{{"x": .5, "y": 2}, {"x": -1.25, "y": .75}} {
+		values := p.Evaluate(in)
+		for i, n := range expressions {
+			if expected := n.Calculate(in); values[i] != expected {
+				t.Fatal("incorrect value", i, values[i], expected)
+			}
+		}
+	}
+
+	// the terms of folded derivatives that no output needs are swept
+	b, err := Parse("diff(sin(x*y)*x^3, x) + sin(x*y)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, uses := range NewPlan(b).Uses {
+		if uses == 0 {
+			t.Fatal("unused term", i)
+		}
+	}
+	source, err := Emit(&GoEmitter{Package: "generated"}, nil, Function{Name: "F", Expression: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; strings.Contains(string(source), fmt.Sprintf("t_%d :=", i)); i++ {
+		// a temporary is declared and used more than once
+		if name := fmt.Sprintf("t_%d", i); strings.Count(string(source), name) < 3 {
+			t.Fatal("unneeded temporary", name, string(source))
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	rule, err := ParseRule("sin(?a)^2 + cos(?a)^2 -> 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.LHS.String() != "((sin(?a)^2) + (cos(?a)^2))" || rule.RHS.String() != "1" {
+		t.Fatal("incorrect rule", rule.LHS, rule.RHS)
+	}
+	for _, expression := range []string{"?a + 1", "cos(?a)", "-?a"} {
+		if _, err := Parse(expression); err == nil {
+			t.Fatal("expected pattern variables to only parse in rules", expression)
+		}
+	}
+	r := NewRewriter(rule)
+	for expression, expected := range map[string]string{
+		"x*(sin(x*y)^2 + cos(x*y)^2)": "(x * 1)",
+		"sin(x)^2 + cos(y)^2":         "((sin(x)^2) + (cos(y)^2))",
+	} {
+		a, err := Parse(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := r.Rewrite(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != expected {
+			t.Fatal("incorrect rewrite", expression, b, expected)
+		}
+	}
+
+	for _, invalid := range []string{"?a + 1", "?a -> ?b", "?a ->"} {
+		if _, err := ParseRule(invalid); err == nil {
+			t.Fatal("expected an error", invalid)
+		}
+	}
+
+	double, err := ParseRule("?a + ?a -> 2*?a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	positive, err := ParseRule("?a - ?b -> 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	positive.Condition = func(b Bindings) bool {
+		return b["a"].Equal(b["b"])
+	}
+	a, err := Parse("(x*y + x*y) - (2*(x*y))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewRewriter(double, positive).Rewrite(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "0" {
+		t.Fatal("incorrect rewrite", b)
+	}
+
+	commute, err := ParseRule("?a + ?b -> ?b + ?a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited := NewRewriter(commute)
+	limited.Limit = 64
+	if _, err := limited.Rewrite(a); err != ErrRewriteLimit {
+		t.Fatal("expected the limit to be exceeded", err)
+	}
+
+	simplify := NewRewriter(DefaultRules()...)
+	for _, expression := range []string{
+		"x*y + 0",
+		"sin(x*y)*cos(x) + x^3/y + pi",
+		"(x3*sin(x*x1))/x4 + x2*x^3*cos(x*x1) - x^x2 + x3%2",
+		"0^x + 1^x + x^1 + x^0 + x%1 + x/1",
+	} {
+		a, err := Parse(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := a.Derivative(map[string]bool{"x": true})
+		c, err := simplify.Rewrite(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := b.Simplify(); !c.Equal(expected) {
+			t.Fatal("incorrect simplification", expression, c, expected)
+		}
+	}
+	a, err = Parse("x^3/y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = simplify.Rewrite(a.Derivative(map[string]bool{"x1": true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "0" {
+		t.Fatal("expected a fixed point", b)
+	}
+}
+
+func TestEGraph(t *testing.T) {
+	g := NewEGraph()
+	x, y := g.Add(&Node{Operation: OperationVariable, Variable: "x"}), g.Add(&Node{Operation: OperationVariable, Variable: "y"})
+	a, err := Parse("sin(x) + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("sin(y) + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ia, ib := g.Add(a), g.Add(b)
+	if g.Find(ia) == g.Find(ib) || !g.Union(x, y) || g.Union(y, x) {
+		t.Fatal("incorrect union")
+	}
+	g.Rebuild()
+	if g.Find(ia) != g.Find(ib) {
+		t.Fatal("expected congruent classes to be merged")
+	}
+
+	cost := func(n *Node, f CostFunction) float64 {
+		var process func(n *Node) float64
+		process = func(n *Node) float64 {
+			children := []float64{}
+			for _, child := range n.children() {
+				children = append(children, process(child))
+			}
+			return f(n, children)
+		}
+		return process(n)
+	}
+	inputs := []map[string]float64{{"x": .5, "y": 2}, {"x": -1.25, "y": .75}}
+	for _, expression := range []string{"x/y", "x*2 + x*3", "sin(x)/cos(x)", "x - -y"} {
+		a, err := Parse(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := a.Derivative(map[string]bool{"x": true})
+		for _, f := range []CostFunction{NodeCount, EvaluationCost, Stability} {
+			b := Optimize(d, nil, f, SaturationOptions{Nodes: 2048})
+			if cost(b, f) > cost(d, f) {
+				t.Fatal("optimized tree is more expensive", expression, b, d)
+			}
+			for _, in := range inputs {
+				if v, expected := b.Calculate(in), d.Calculate(in); math.Abs(v-expected) > 1e-9 {
+					t.Fatal("optimized tree isn't equivalent", expression, b, v, expected)
+				}
+			}
+		}
+	}
+	a, err = Parse("x/y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := a.Derivative(map[string]bool{"x": true})
+	if b := Optimize(d, nil, NodeCount, SaturationOptions{Nodes: 2048}); b.String() != "(1 / y)" {
+		t.Fatal("expected the derivative of a quotient to be compact", d.Simplify(), b)
+	}
+
+	g = NewEGraph()
+	if id := g.Add(d); !g.Saturate(DefaultRules(), SaturationOptions{}) {
+		t.Fatal("expected the default rules to saturate")
+	} else if b, c := g.Extract(id, NodeCount); b.String() != "(y / (y^2))" || c != 5 {
+		t.Fatal("incorrect extraction", b, c)
+	}
+	g = NewEGraph()
+	g.Add(d)
+	if g.Saturate(AlgebraicRules(), SaturationOptions{Nodes: 64}) || g.Nodes() > 2*64 {
+		t.Fatal("expected the node limit to stop saturation", g.Nodes())
+	}
+}
+
+func TestPolynomial(t *testing.T) {
+	cases := []struct {
+		expression, expanded, collected, factored string
+	}{
+		{"(x+1)^3", "((((x^3) + (3 * (x^2))) + (3 * x)) + 1)", "((((x^3) + (3 * (x^2))) + (3 * x)) + 1)", "((x + 1)^3)"},
+		{"(x+y)^2 - x*(x-y)", "(((3 * x) * y) + (y^2))", "(((3 * y) * x) + (y^2))", "(y * ((3 * x) + y))"},
+		{"2*x^3 - 2*x", "((2 * (x^3)) - (2 * x))", "((2 * (x^3)) - (2 * x))", "(((2 * x) * (x - 1)) * (x + 1))"},
+		{"x^4 - 1", "((x^4) - 1)", "((x^4) - 1)", "(((x - 1) * (x + 1)) * ((x^2) + 1))"},
+		{"6*x^2 - x - 1", "(((6 * (x^2)) - x) - 1)", "(((6 * (x^2)) - x) - 1)", "(((2 * x) - 1) * ((3 * x) + 1))"},
+		{"x/2 + y/3", "(((1 / 2) * x) + ((1 / 3) * y))", "(((1 / 2) * x) + ((1 / 3) * y))", "((1 / 6) * ((3 * x) + (2 * y)))"},
+		{"-(x^2) - x*y", "(-((x^2)) - (x * y))", "(-((x^2)) - (y * x))", "-((x * (x + y)))"},
+		{"0*x", "0", "0", "0"},
+	}
+	inputs := []map[string]float64{{"x": .5, "y": 2}, {"x": -1.25, "y": .75}}
+	for _, c := range cases {
+		a, err := Parse(c.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expanded, err := Expand(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		collected, err := Collect(a, "x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		factored, err := Factor(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expanded.String() != c.expanded || collected.String() != c.collected || factored.String() != c.factored {
+			t.Fatal("incorrect forms", c.expression, expanded, collected, factored)
+		}
+		p, err := NewPolynomial(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.Factor().Polynomial().Equal(p) {
+			t.Fatal("factorization doesn't multiply out", c.expression)
+		}
+		for _, in := range inputs {
+			expected := a.Calculate(in)
+			for _, b := range []*Node{expanded, collected, factored} {
+				if v := b.Calculate(in); math.Abs(v-expected) > 1e-9 {
+					t.Fatal("incorrect value", c.expression, b, v, expected)
+				}
+			}
+		}
+	}
+
+	a, err := Parse("(x*y + 2)^2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPolynomial(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coefficients := p.Coefficients("x")
+	if len(coefficients) != 3 || coefficients[2].String() != "(y^2)" || coefficients[1].String() != "(4 * y)" || coefficients[0].String() != "4" {
+		t.Fatal("incorrect coefficients", coefficients)
+	}
+	if c := p.Coefficient(map[string]int{"x": 1, "y": 1}); c.Cmp(big.NewRat(4, 1)) != 0 {
+		t.Fatal("incorrect coefficient", c)
+	}
+	if p.Degree("x") != 2 || p.Degree("z") != 0 {
+		t.Fatal("incorrect degree")
+	}
+
+	// irreducible quadratic and multivariate factors over the rationals
+	factorizations := []struct {
+		expression, factored string
+	}{
+		{"x^2 + 1", "((x^2) + 1)"},
+		{"x^4 + 4", "((((x^2) + (2 * x)) + 2) * (((x^2) - (2 * x)) + 2))"},
+		{"x^4 + x^2 + 1", "((((x^2) + x) + 1) * (((x^2) - x) + 1))"},
+		{"(x^2 + 1)^2*(x - 1)", "((x - 1) * (((x^2) + 1)^2))"},
+		{"x^2 - y^2", "((x + y) * (x - y))"},
+		{"x^2 + y^2", "((x^2) + (y^2))"},
+		{"x^3 - y^3", "((x - y) * (((x^2) + (x * y)) + (y^2)))"},
+		{"2*x^2 + 4*x*y + 2*y^2", "(2 * ((x + y)^2))"},
+		{"y - x^2*y^3", "-(((y * ((x * y) + 1)) * ((x * y) - 1)))"},
+	}
+	for _, c := range factorizations {
+		a, err := Parse(c.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewPolynomial(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Factor()
+		if f.Node().String() != c.factored || f.Partial || !f.Polynomial().Equal(p) {
+			t.Fatal("incorrect factorization", c.expression, f.Node(), f.Partial)
+		}
+	}
+
+	for _, invalid := range []string{"sin(x)", "x^y", "x/y", "2^(0-1)", "x % 2", "pi*x"} {
+		a, err := Parse(invalid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewPolynomial(a); !errors.Is(err, ErrNotPolynomial) {
+			t.Fatal("expected an error", invalid, err)
+		}
+	}
+}
+
+func TestRational(t *testing.T) {
+	cases := []struct {
+		expression, together, apart string
+	}{
+		{"(x^2-1)/(x-1)", "(x + 1)", "(x + 1)"},
+		{"x/(x+1)", "(x / (x + 1))", "(1 + (-(1) / (x + 1)))"},
+		{"1/(x^2-1)", "(1 / ((x^2) - 1))", "(((1 / 2) / (x - 1)) + (-((1 / 2)) / (x + 1)))"},
+		{"(x^3+1)/(x^2-2*x+1)", "(((x^3) + 1) / (((x^2) - (2 * x)) + 1))", "(((x + 2) + (3 / (x - 1))) + (2 / ((x - 1)^2)))"},
+		{"(x+2)/(x*(x^2+1))", "((x + 2) / ((x^3) + x))", "((2 / x) + ((-((2 * x)) + 1) / ((x^2) + 1)))"},
+		{"1/x - 1/(x+1)", "(1 / ((x^2) + x))", "((1 / x) + (-(1) / (x + 1)))"},
+	}
+	inputs := []map[string]float64{{"x": .5}, {"x": -2.25}, {"x": 3}}
+	for _, c := range cases {
+		a, err := Parse(c.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		together, err := Together(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apart, err := Apart(a, "x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if together.String() != c.together || apart.String() != c.apart {
+			t.Fatal("incorrect forms", c.expression, together, apart)
+		}
+		for _, in := range inputs {
+			expected := a.Calculate(in)
+			for _, b := range []*Node{together, apart} {
+				if v := b.Calculate(in); math.Abs(v-expected) > 1e-9 {
+					t.Fatal("incorrect value", c.expression, b, v, expected)
+				}
+			}
+		}
+	}
+
+	derivatives := []struct {
+		expression, derivative string
+	}{
+		{"x/(x+1)", "(1 / (((x^2) + (2 * x)) + 1))"},
+		{"(x^2*y-y)/(x*y+y)", "1"},
+		{"(a^2-b^2)/(a+b)^2 + x/a", "(1 / a)"},
+	}
+	for _, c := range derivatives {
+		a, err := Parse(c.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := Together(a.Derivative(map[string]bool{"x": true}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.String() != c.derivative {
+			t.Fatal("derivative not in lowest terms", c.expression, d)
+		}
+	}
+
+	p, err := NewPolynomial(&Node{Operation: OperationNumber, Value: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2]string{{"(x+y)*(x-y)*(2*x+z)", "(x+y)^2*(x*z+1)"}, {"6*x^2*y + 3*x", "4*x*y^2 + 2*y"}} {
+		a, err := Parse(pair[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse(pair[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, _ := NewPolynomial(a)
+		y, _ := NewPolynomial(b)
+		g := GCD(x, y)
+		if _, ok := x.Quo(g); !ok {
+			t.Fatal("gcd doesn't divide", pair[0], g)
+		}
+		if _, ok := y.Quo(g); !ok {
+			t.Fatal("gcd doesn't divide", pair[1], g)
+		}
+		p = p.Mul(g)
+	}
+	expected, err := Parse("(x+y)*(2*x*y+1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q, _ := NewPolynomial(expected); !p.Equal(q) {
+		t.Fatal("incorrect gcd", p)
+	}
+
+	for _, invalid := range []string{"sin(x)/x", "x^(1/2)", "1/(x-x)"} {
+		a, err := Parse(invalid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Together(a); !errors.Is(err, ErrNotRational) {
+			t.Fatal("expected an error", invalid, err)
+		}
+	}
+	a, err := Parse("1/(x*y+1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Apart(a, "x"); !errors.Is(err, ErrNotRational) {
+		t.Fatal("expected an error", err)
+	}
+}
+
+func TestTrig(t *testing.T) {
+	cases := []struct {
+		expression string
+		strategies TrigStrategy
+		simplified string
+	}{
+		{"sin(x)^2 + cos(x)^2", TrigDefault, "1"},
+		{"y + sin(x)^2 + cos(x)^2", TrigDefault, "(y + 1)"},
+		{"1 - cos(2*x)^2", TrigDefault, "(sin((2 * x))^2)"},
+		{"sin(-x)", TrigParity, "-(sin(x))"},
+		{"cos(-x)", TrigParity, "cos(x)"},
+		{"cos(pi)", TrigSpecialValues, "-(1)"},
+		{"sin(pi/2)", TrigSpecialValues, "1"},
+		{"cos(pi/3)", TrigSpecialValues, "(1 / 2)"},
+		{"sin(5*pi/4)", TrigSpecialValues, "-((sqrt(2) / 2))"},
+		{"sin(2*pi) + x", TrigSpecialValues, "x"},
+		{"sin(3)", TrigDefault, "sin(3)"},
+		{"sin(x)^2 + cos(x)^2", TrigParity, "((sin(x)^2) + (cos(x)^2))"},
+		{"sin(2*x)", TrigExpand, "((2 * sin(x)) * cos(x))"},
+		{"cos(x+y)", TrigExpand, "((cos(x) * cos(y)) - (sin(x) * sin(y)))"},
+		{"cos(x-pi)", TrigDefault | TrigExpand, "(cos(x) * -(1))"},
+		{"cos(x)^2 - sin(x)^2", TrigContract, "cos((2 * x))"},
+		{"sin(x)*cos(y) - cos(x)*sin(y)", TrigContract, "sin((x - y))"},
+	}
+	inputs := []map[string]float64{{"x": .5, "y": 2}, {"x": -1.25, "y": .75}}
+	for _, c := range cases {
+		a, err := Parse(c.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := SimplifyTrig(a, c.strategies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != c.simplified {
+			t.Fatal("incorrect simplification", c.expression, b)
+		}
+		for _, in := range inputs {
+			if x, y := a.Calculate(in), b.Calculate(in); math.Abs(x-y) > 1e-9 {
+				t.Fatal("incorrect value", c.expression, b, x, y)
+			}
+		}
+	}
+
+	for _, s := range []string{"sin(2*x)", "cos(x+y)", "sin(x-y)", "cos(2*x)"} {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expanded, err := SimplifyTrig(a, TrigExpand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contracted, err := SimplifyTrig(expanded, TrigContract)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contracted.Equal(a) {
+			t.Fatal("contraction doesn't undo expansion", s, expanded, contracted)
+		}
+	}
+
+	a, err := Parse("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SimplifyTrig(a, TrigExpand|TrigContract); !errors.Is(err, ErrTrigStrategy) {
+		t.Fatal("expected an error", err)
+	}
+
+	roots := Roots{}
+	for _, s := range []string{"cos(x)^2 + sin(x)^2 + x", "x + 1", "sin(-x)", "-(sin(x))"} {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, Root{Root: a})
+	}
+	normal := func(n *Node) *Node {
+		a, err := SimplifyTrig(n, TrigDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a.Canonical()
+	}
+	if !normal(roots[0].Root).Equal(normal(roots[1].Root)) || !normal(roots[2].Root).Equal(normal(roots[3].Root)) {
+		t.Fatal("trig identities not recognized", roots)
+	}
+	// the search only compares roots structurally
+	if unique := roots.Unique(); len(unique) != 4 {
+		t.Fatal("roots unified by trig identities", unique)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	parse := func(s string) *Node {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	a := parse("x^2 + sin(pi*y) - x1*cos(x) % 3")
+	if names := a.FreeVariables(); strings.Join(names, ",") != "x,x1,y" {
+		t.Fatal("incorrect free variables", names)
+	}
+	if names := parse("pi * 2").FreeVariables(); len(names) != 0 {
+		t.Fatal("pi isn't a variable", names)
+	}
+
+	b, err := a.Substitute(map[string]*Node{"x": parse("2*t+1"), "y": parse("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "(((((2 * t) + 1)^2) + sin((pi * x))) - ((x1 * cos(((2 * t) + 1))) % 3))" {
+		t.Fatal("incorrect substitution", b)
+	}
+	if names := b.FreeVariables(); strings.Join(names, ",") != "t,x,x1" {
+		t.Fatal("incorrect free variables", names)
+	}
+	in := map[string]float64{"t": .25, "x": -.5, "x1": 2}
+	if x, y := b.Calculate(in), a.Calculate(map[string]float64{"x": 1.5, "y": -.5, "x1": 2}); math.Abs(x-y) > 1e-12 {
+		t.Fatal("incorrect value", x, y)
+	}
+	if a.String() != "(((x^2) + sin((pi * y))) - ((x1 * cos(x)) % 3))" {
+		t.Fatal("substitution modified the tree", a)
+	}
+
+	swapped, err := parse("x - y").Substitute(map[string]*Node{"x": parse("y"), "y": parse("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swapped.String() != "(y - x)" {
+		t.Fatal("substitution isn't simultaneous", swapped)
+	}
+
+	renamed, err := parse("x1*x2 + x3").Rename(map[string]string{"x1": "mass", "x2": "speed", "x3": "x1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.String() != "((mass * speed) + x1)" {
+		t.Fatal("incorrect rename", renamed)
+	}
+	if reparsed := parse(renamed.String()); !reparsed.Equal(renamed) {
+		t.Fatal("renamed tree doesn't round trip", renamed)
+	}
+
+	if _, err := a.Substitute(map[string]*Node{"pi": parse("3")}); !errors.Is(err, ErrReservedName) {
+		t.Fatal("expected an error", err)
+	}
+	if _, err := a.Substitute(map[string]*Node{"x": nil}); err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, names := range []map[string]string{{"x": "pi"}, {"x": "X"}, {"x": "y"}, {"x": "z", "y": "z"}} {
+		if _, err := a.Rename(names); err == nil {
+			t.Fatal("expected an error", names)
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+	x := &Node{Operation: OperationVariable, Variable: "x"}
+	shifted := &Node{Operation: OperationAdd, Left: x, Right: &Node{Operation: OperationNumber, Value: 1}}
+	parse := func(s string) *Node {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	cases := []struct {
+		expression *Node
+		point      float64
+		order      int
+		series     string
+	}{
+		{&Node{Operation: OperationNaturalExponentiation, Left: x}, 0, 4,
+			"((((1 + x) + ((1 / 2) * (x^2))) + ((1 / 6) * (x^3))) + ((1 / 24) * (x^4)))"},
+		{parse("sin(x)"), 0, 5, "((x - ((1 / 6) * (x^3))) + ((1 / 120) * (x^5)))"},
+		{parse("1/(1-x)"), 0, 3, "(((1 + x) + (x^2)) + (x^3))"},
+		{&Node{Operation: OperationSquareRoot, Left: shifted}, 0, 3,
+			"(((1 + ((1 / 2) * x)) - ((1 / 8) * (x^2))) + ((1 / 16) * (x^3)))"},
+		{&Node{Operation: OperationNaturalLogarithm, Left: x}, 1, 3,
+			"(((x - 1) - ((1 / 2) * ((x - 1)^2))) + ((1 / 3) * ((x - 1)^3)))"},
+		{parse("x^3 - 2*x"), -1, 3, "(((1 + (x + 1)) - (3 * ((x + 1)^2))) + ((x + 1)^3))"},
+		{parse("sin(pi*x)"), 0, 1, "(3.141592653589793 * x)"},
+		{parse("sin(x)"), math.Pi, 3, "(-((x - pi)) + ((1 / 6) * ((x - pi)^3)))"},
+		{parse("2*sin(x)"), math.Pi / 3, 1, "(1.7320508075688774 + (x - ((1 / 3) * pi)))"},
+		{parse("cos(x)"), -math.Pi / 2, 3, "((x + ((1 / 2) * pi)) - ((1 / 6) * ((x + ((1 / 2) * pi))^3)))"},
+	}
+	for _, c := range cases {
+		s, err := Series(c.expression, "x", c.point, c.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Series.String() != c.series {
+			t.Fatal("incorrect series", c.expression, s.Series)
+		}
+		for _, h := range []float64{.1, -.05} {
+			in := map[string]float64{"x": c.point + h}
+			difference := math.Abs(c.expression.Calculate(in) - s.Series.Calculate(in))
+			if estimate := s.Remainder(c.point + h); difference > 2*estimate+1e-12 {
+				t.Fatal("remainder underestimated", c.expression, difference, estimate)
+			}
+		}
+	}
+
+	s, err := Series(&Node{Operation: OperationNaturalExponentiation, Left: x}, "x", 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Exact[6].Cmp(big.NewRat(1, 720)) != 0 || s.Coefficients[6] != 1.0/720 {
+		t.Fatal("incorrect coefficient", s.Exact[6], s.Coefficients[6])
+	}
+	s, err = Series(parse("cos(pi*x)"), "x", 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Exact[2] != nil || s.Exact[0].Cmp(big.NewRat(1, 1)) != 0 {
+		t.Fatal("pi^2/2 isn't rational", s.Exact)
+	}
+
+	for _, singular := range []*Node{
+		parse("1/x"),
+		&Node{Operation: OperationNaturalLogarithm, Left: x},
+		&Node{Operation: OperationSquareRoot, Left: x},
+	} {
+		if _, err := Series(singular, "x", 0, 3); !errors.Is(err, ErrSingularPoint) {
+			t.Fatal("expected a singular point", singular, err)
+		}
+	}
+	if _, err := Series(parse("x*y"), "x", 0, 3); err == nil {
+		t.Fatal("expected an error for a free variable")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	x := &Node{Operation: OperationVariable, Variable: "x"}
+	exp := &Node{Operation: OperationNaturalExponentiation, Left: x}
+	log := &Node{Operation: OperationNaturalLogarithm, Left: x}
+	parse := func(s string) *Node {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	inf := math.Inf(1)
+	cases := []struct {
+		expression *Node
+		point      float64
+		direction  Direction
+		status     LimitStatus
+		value      string
+	}{
+		{parse("sin(x)/x"), 0, DirectionBoth, LimitExists, "1"},
+		{parse("sin(x)/(x-pi)"), math.Pi, DirectionBoth, LimitExists, "-(1)"},
+		{parse("sin(x)/(x-pi)"), math.Pi, DirectionRight, LimitExists, "-(1)"},
+		{parse("(x-pi)/sin(x)"), math.Pi, DirectionBoth, LimitExists, "-(1)"},
+		{parse("cos(x)/(x-pi/2)"), math.Pi / 2, DirectionBoth, LimitExists, "-(1)"},
+		{parse("sin(x)/(x-pi)^2"), math.Pi, DirectionLeft, LimitInfinite, "+Inf"},
+		{parse("(1-cos(x))/x^2"), 0, DirectionBoth, LimitExists, "(1 / 2)"},
+		{parse("(x^2-1)/(x-1)"), 1, DirectionBoth, LimitExists, "2"},
+		{parse("sin(pi*x)/x"), 0, DirectionBoth, LimitExists, "pi"},
+		{parse("x*sin(1/x)"), 0, DirectionBoth, LimitExists, "0"},
+		{parse("x^x"), 0, DirectionRight, LimitExists, "1"},
+		{parse("(1 + 1/x)^x"), inf, DirectionBoth, LimitExists, "e"},
+		{parse("(2*x^2+3)/(x^2-x)"), inf, DirectionBoth, LimitExists, "2"},
+		{&Node{Operation: OperationDivide, Left: parse("x^10"), Right: exp}, inf, DirectionBoth, LimitExists, "0"},
+		{&Node{Operation: OperationMultiply, Left: x, Right: log}, 0, DirectionRight, LimitExists, "0"},
+		{&Node{Operation: OperationDivide, Left: log, Right: x}, inf, DirectionBoth, LimitExists, "0"},
+		{&Node{Operation: OperationMultiply, Left: exp, Right: x}, -inf, DirectionBoth, LimitExists, "0"},
+		{parse("sin(x)/x").Derivative(map[string]bool{"x": true}), 0, DirectionBoth, LimitExists, "0"},
+		{parse("1/x"), 0, DirectionRight, LimitInfinite, "+Inf"},
+		{parse("1/x"), 0, DirectionLeft, LimitInfinite, "-Inf"},
+		{parse("1/x^2"), 0, DirectionBoth, LimitInfinite, "+Inf"},
+		{parse("1/x - 1/x^2"), 0, DirectionRight, LimitInfinite, "-Inf"},
+		{parse("x - sin(x)"), inf, DirectionBoth, LimitInfinite, "+Inf"},
+		{parse("x^2 - x"), inf, DirectionBoth, LimitInfinite, "+Inf"},
+		{parse("1/x"), 0, DirectionBoth, LimitDoesNotExist, "<nil>"},
+		{parse("sin(1/x)"), 0, DirectionBoth, LimitDoesNotExist, "<nil>"},
+		{parse("sin(x)"), inf, DirectionBoth, LimitDoesNotExist, "<nil>"},
+	}
+	for _, c := range cases {
+		l, err := Limit(c.expression, "x", c.point, c.direction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value := "<nil>"
+		if l.Value != nil {
+			value = l.Value.String()
+		}
+		if l.Status != c.status || value != c.value {
+			t.Fatal("incorrect limit", c.expression, c.point, c.direction, l.Status, value)
+		}
+	}
+	if _, err := Limit(parse("x*y"), "x", 0, DirectionBoth); err == nil {
+		t.Fatal("expected an error for a free variable")
+	}
+}
+
+func TestQuad(t *testing.T) {
+	x := &Node{Operation: OperationVariable, Variable: "x"}
+	parse := func(s string) *Node {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	gaussian := &Node{
+		Operation: OperationNaturalExponentiation,
+		Left:      &Node{Operation: OperationNegate, Left: parse("x^2")},
+	}
+	log := &Node{Operation: OperationNaturalLogarithm, Left: x}
+	inf := math.Inf(1)
+	cases := []struct {
+		expression *Node
+		a, b       float64
+		value      float64
+	}{
+		{parse("x^2"), 0, 1, 1.0 / 3},
+		{parse("x^2"), 1, 0, -1.0 / 3},
+		{parse("sin(x)"), 0, math.Pi, 2},
+		{parse("1/(1+x^2)"), -inf, inf, math.Pi},
+		{parse("1/(1+x^2)"), 0, inf, math.Pi / 2},
+		{parse("1/x^2"), -inf, -1, 1},
+		{gaussian, -inf, inf, math.Sqrt(math.Pi)},
+		{&Node{Operation: OperationDivide, Left: parse("1"), Right: &Node{Operation: OperationSquareRoot, Left: x}}, 0, 1, 2},
+		{log, 0, 1, -1},
+		{parse("sin(x)/x"), 0, 0, 0},
+	}
+	for _, c := range cases {
+		r, err := Quad(c.expression, "x", c.a, c.b, 1e-10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(r.Value-c.value) > 1e-9 || r.Error > 1e-9 {
+			t.Fatal("incorrect integral", c.expression, c.a, c.b, r.Value, r.Error)
+		}
+	}
+
+	checks := []struct {
+		expression, antiderivative *Node
+		a, b                       float64
+		matches                    bool
+	}{
+		{parse("x^2"), parse("x^3/3"), 0, 3, true},
+		{parse("x^2"), parse("x^3/2"), 0, 3, false},
+		{parse("1/x^2"), parse("-(1/x)"), 1, inf, true},
+		{parse("cos(x)"), parse("sin(x)"), 2, -1, true},
+		{log, &Node{Operation: OperationSubtract, Left: &Node{Operation: OperationMultiply, Left: x, Right: log}, Right: x}, 0, 2, true},
+	}
+	for _, c := range checks {
+		r, err := Quad(c.expression, "x", c.a, c.b, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		difference, matches, err := r.Check(c.antiderivative)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matches != c.matches {
+			t.Fatal("incorrect antiderivative check", c.antiderivative, difference)
+		}
+	}
+
+	if _, err := Quad(parse("1/x"), "x", -1, 1, 0); !errors.Is(err, ErrQuadNonFinite) {
+		t.Fatal("expected a non finite integrand", err)
+	}
+	if _, err := Quad(parse("sin(1/x)/x"), "x", 0, 1, 1e-12); !errors.Is(err, ErrQuadTolerance) {
+		t.Fatal("expected the tolerance not to be reached", err)
+	}
+	if _, err := Quad(parse("x*y"), "x", 0, 1, 0); err == nil {
+		t.Fatal("expected an error for a free variable")
+	}
+
+	// a done context stops the bisection
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := QuadContext(ctx, parse("sin(1/x)/x"), "x", 0, 1, 1e-12); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected the quadrature to be canceled", err)
+	}
+	if _, err := parse("integrate(sin(1/t)/t, t, 0, x)").CalculateContext(ctx, map[string]float64{"x": 1}); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected the integral to be canceled", err)
+	}
+}
+
+func TestCalculus(t *testing.T) {
+	parse := func(s string) *Node {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	cases := []struct {
+		expression, form string
+		value            float64
+	}{
+		{"integrate(x^2*sin(x), x, 0, pi)", "integrate(((x^2) * sin(x)), x, 0, pi)", math.Pi*math.Pi - 4},
+		{"diff(x^x, x)", "diff((x^x), x)", 4 * (math.Log(2) + 1)},
+		{"diff(integrate(sin(t), t, 0, x^2), x)", "diff(integrate(sin(t), t, 0, (x^2)), x)", 4 * math.Sin(4)},
+		{"integrate(x*y, y, 0, x) + 1", "(integrate((x * y), y, 0, x) + 1)", 5},
+	}
+	for _, c := range cases {
+		a := parse(c.expression)
+		if a.String() != c.form {
+			t.Fatal("incorrect form", c.expression, a.String())
+		}
+		if !parse(a.String()).Equal(a) {
+			t.Fatal("form doesn't round trip", a.String())
+		}
+		if v := a.Calculate(map[string]float64{"x": 2}); math.Abs(v-c.value) > 1e-9 {
+			t.Fatal("incorrect value", c.expression, v, c.value)
+		}
+	}
+	if names := parse("integrate(x*y, y, 0, z)").FreeVariables(); !slices.Equal(names, []string{"x", "z"}) {
+		t.Fatal("incorrect free variables", names)
+	}
+	b, err := parse("integrate(x*y, y, 0, 1)").Substitute(map[string]*Node{"y": parse("2")})
+	if err != nil || b.String() != "integrate((x * y), y, 0, 1)" {
+		t.Fatal("bound variable substituted", b, err)
+	}
+	if _, err := parse("integrate(x, x)").Substitute(map[string]*Node{"x": parse("2")}); err == nil {
+		t.Fatal("expected an error for the variable of an indefinite integral")
+	}
+
+	// a cancelled search falls back to quadrature
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b, err = parse("integrate(x^2*sin(x), x, 0, pi)").Evaluate(ctx, EvaluateOptions{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Operation != OperationNumber || math.Abs(b.Value-(math.Pi*math.Pi-4)) > 1e-9 {
+		t.Fatal("incorrect integral", b)
+	}
+	b, err = parse("integrate(3, x, 1, 4)").Evaluate(ctx, EvaluateOptions{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Operation == OperationNumber || b.Calculate(nil) != 9 {
+		t.Fatal("expected an exact integral", b)
+	}
+	b, err = parse("diff(integrate(sin(t), t, 0, x^2), x)").Evaluate(ctx, EvaluateOptions{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := b.Calculate(map[string]float64{"x": 2}); math.Abs(v-4*math.Sin(4)) > 1e-9 {
+		t.Fatal("incorrect derivative", b, v)
+	}
+	if _, err := parse("integrate(x^2*sin(x), x)").Evaluate(ctx, EvaluateOptions{Depth: 3}); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected a cancelled search", err)
+	}
+
+	// other variables are constant factors and terms
+	a := parse("integrate(a*x*b - c, x)")
+	b, err = a.Evaluate(context.Background(), EvaluateOptions{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{"a": 3, "b": 2, "c": 5, "x": 2}
+	d := b.Derivative(map[string]bool{"x": true})
+	if v, expected := d.Calculate(values), a.Left.Calculate(values); math.Abs(v-expected) > 1e-9 {
+		t.Fatal("incorrect antiderivative", b, v, expected)
+	}
+	if _, err := parse("integrate(sin(a*x), x)").Evaluate(context.Background(), EvaluateOptions{Depth: 3}); err == nil {
+		t.Fatal("expected an error for a variable that isn't a constant factor")
+	}
+	_, err = parse("integrate(x^2*sin(x), x)").Evaluate(context.Background(), EvaluateOptions{SearchTimeout: time.Nanosecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected the search to time out", err)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	parse := func(s string) *Node {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	a := parse("x^2 - 3*x + 2 = 0")
+	if a.Operation != OperationEquation || a.String() != "(((x^2) - (3 * x)) + 2) = 0" {
+		t.Fatal("incorrect equation", a)
+	}
+	if !parse(a.String()).Equal(a) {
+		t.Fatal("equation doesn't round trip", a)
+	}
+	if v := a.Calculate(map[string]float64{"x": 3}); v != 2 {
+		t.Fatal("incorrect residual", v)
+	}
+
+	x := &Node{Operation: OperationVariable, Variable: "x"}
+	cases := []struct {
+		equation  *Node
+		solutions []string
+	}{
+		{a, []string{"1", "2"}},
+		{parse("2*x + 3 = 7"), []string{"2"}},
+		{parse("5 = 2*x"), []string{"(5 / 2)"}},
+		{parse("x^2 = 2"), []string{"-(sqrt(2))", "sqrt(2)"}},
+		{parse("x^2 + 1 = 0"), nil},
+		{parse("x^2 - 2*x + 1"), []string{"1"}},
+		{parse("x/(x - 1) = 2"), []string{"2"}},
+		{parse("(x^2 - 1)/(x - 1) = 0"), []string{"-(1)"}},
+		{parse("(x^2 - 1)/(x - 1) = 2"), nil},
+		{parse("1/x + 1/(x - 1) = 0"), []string{"(1 / 2)"}},
+		{parse("a/(x - b) = 1"), []string{"(a + b) if a != 0"}},
+		{parse("x^3 - 6*x^2 + 11*x - 6 = 0"), []string{"1", "2", "3"}},
+		{parse("(x - 1)^3 = -8"), []string{"-(1)"}},
+		{parse("(2*x + 1)^(1/2) = 3"), []string{"4"}},
+		{parse("2^x = 8"), []string{"3"}},
+		{parse("a*x + b = c"), []string{"((-(b) + c) / a) if a != 0"}},
+		{parse("x^2 = y"), []string{"-(sqrt(y)) if y >= 0", "sqrt(y) if y >= 0"}},
+		{parse("x^3 = y"), []string{"-((-(y)^(1 / 3))) if y < 0", "(y^(1 / 3)) if y >= 0"}},
+		{&Node{Operation: OperationEquation, Left: &Node{Operation: OperationNaturalExponentiation, Left: x},
+			Right: &Node{Operation: OperationNumber, Value: 2}}, []string{"log(2)"}},
+		{&Node{Operation: OperationEquation, Left: &Node{Operation: OperationSquareRoot, Left: x},
+			Right: &Node{Operation: OperationNumber, Value: -1}}, nil},
+	}
+	for _, c := range cases {
+		solutions, err := Solve(c.equation, "x")
+		if err != nil {
+			t.Fatal(c.equation, err)
+		}
+		s := make([]string, len(solutions))
+		for i, solution := range solutions {
+			s[i] = solution.String()
+			if len(solution.Conditions) > 0 {
+				continue
+			}
+			if v := c.equation.Calculate(map[string]float64{"x": solution.Value.Calculate(nil)}); math.Abs(v) > 1e-9 {
+				t.Fatal("solution doesn't solve the equation", c.equation, solution, v)
+			}
+		}
+		if !slices.Equal(s, c.solutions) {
+			t.Fatal("incorrect solutions", c.equation, s)
+		}
+	}
+
+	solutions, err := Solve(parse("a*x^2 + b*x + c = 0"), "x")
+	if err != nil || len(solutions) != 3 {
+		t.Fatal("incorrect quadratic solutions", solutions, err)
+	}
+	values := map[string]float64{"a": 2, "b": -3, "c": 1}
+	for _, solution := range solutions[1:] {
+		values["x"] = solution.Value.Calculate(values)
+		if v := parse("a*x^2 + b*x + c").Calculate(values); math.Abs(v) > 1e-9 {
+			t.Fatal("incorrect quadratic solution", solution, v)
+		}
+	}
+	if solutions[0].String() != "(-(c) / b) if a = 0 and b != 0" {
+		t.Fatal("incorrect degenerate solution", solutions[0])
+	}
+
+	if _, err := Solve(parse("x = x"), "x"); !errors.Is(err, ErrInfiniteSolutions) {
+		t.Fatal("expected infinitely many solutions", err)
+	}
+	_, err = Solve(parse("x/x = 1"), "x")
+	if !errors.Is(err, ErrInfiniteSolutions) || !strings.HasSuffix(err.Error(), "every x if x != 0") {
+		t.Fatal("expected infinitely many solutions with x != 0", err)
+	}
+	for _, e := range []string{"x^3 + x = 1", "cos(x) = 1", "y = 2"} {
+		if _, err := Solve(parse(e), "x"); !errors.Is(err, ErrNotSolvable) {
+			t.Fatal("expected an unsolvable equation", e, err)
+		}
+	}
+}
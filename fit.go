@@ -0,0 +1,180 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+const (
+	// DefaultLearningRate is the default learning rate for fitting
+	DefaultLearningRate = .0001
+	// DefaultIterations is the default number of fitting iterations
+	DefaultIterations = 8 * 1024
+	// DefaultTolerance is the default gradient length tolerance
+	DefaultTolerance = 1e-8
+	// DefaultTarget is the default name of the observed variable
+	DefaultTarget = "y"
+)
+
+// Dataset is a set of observations, each binding the variables of a model
+// and the target variable to values
+type Dataset []map[string]float64
+
+// FitOptions are the options for Fit
+type FitOptions struct {
+	// Target is the variable holding the observed value
+	Target string
+	// LearningRate is the step size of the default gradient descent
+	LearningRate float64
+	// Iterations is the maximum number of iterations
+	Iterations int
+	// Tolerance stops the fit when the gradient length falls below it,
+	// defaults to DefaultTolerance
+	Tolerance float64
+	// LossTolerance stops the fit when the change in loss falls below it
+	LossTolerance float64
+	// Optimizer is the optimizer, defaults to normalized gradient descent
+	Optimizer Optimizer
+	// Initial are the starting values of the parameters
+	Initial map[string]float64
+	// Rng initializes parameters missing from Initial
+	Rng *rand.Rand
+}
+
+// FitResult is the result of a fit
+type FitResult struct {
+	// Values are the fitted parameter values
+	Values map[string]float64
+	// Loss is the final mean squared error
+	Loss float64
+	// Iterations is the number of iterations performed
+	Iterations int
+	// Converged is true if a stopping criterion was met
+	Converged bool
+}
+
+// Loss builds the squared error loss (model - target)^2
+func Loss(model *Node, target string) *Node {
+	return &Node{
+		Operation: OperationExponentiation,
+		Left: &Node{
+			Operation: OperationSubtract,
+			Left:      model,
+			Right: &Node{
+				Operation: OperationVariable,
+				Variable:  target,
+			},
+		},
+		Right: &Node{
+			Operation: OperationNumber,
+			Value:     2.0,
+		},
+	}
+}
+
+// Fit fits the parameters of a model to data by minimizing the mean squared
+// error loss using the symbolic partial derivatives of the loss
+func Fit(model *Node, params []string, data Dataset, opts FitOptions) (*FitResult, error) {
+	if model == nil {
+		return nil, errors.New("fit: nil model")
+	}
+	if len(params) == 0 {
+		return nil, errors.New("fit: no parameters")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("fit: no data")
+	}
+	if opts.Target == "" {
+		opts.Target = DefaultTarget
+	}
+	if opts.LearningRate == 0 {
+		opts.LearningRate = DefaultLearningRate
+	}
+	if opts.Iterations == 0 {
+		opts.Iterations = DefaultIterations
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+	if opts.Optimizer == nil {
+		opts.Optimizer = &GradientDescent{
+			Rate:      opts.LearningRate,
+			Normalize: true,
+		}
+	}
+	if opts.Rng == nil {
+		opts.Rng = rand.New(rand.NewSource(1))
+	}
+
+	loss := Loss(model, opts.Target)
+	partials := make([]*Node, len(params))
+	for i, p := range params {
+		partials[i] = loss.Derivative(map[string]bool{p: true}).Simplify()
+	}
+
+	x := make([]float64, len(params))
+	for i, p := range params {
+		if v, ok := opts.Initial[p]; ok {
+			x[i] = v
+		} else {
+			x[i] = opts.Rng.Float64()
+		}
+	}
+	bind := func(datum map[string]float64, x []float64) map[string]float64 {
+		values := make(map[string]float64, len(datum)+len(x))
+		for k, v := range datum {
+			values[k] = v
+		}
+		for i, p := range params {
+			values[p] = x[i]
+		}
+		return values
+	}
+	objective := func(x []float64) (float64, []float64) {
+		l, dx := 0.0, make([]float64, len(partials))
+		for _, datum := range data {
+			values := bind(datum, x)
+			l += loss.Calculate(values)
+			for j, v := range partials {
+				dx[j] += v.Calculate(values)
+			}
+		}
+		for j := range dx {
+			dx[j] /= float64(len(data))
+		}
+		return l / float64(len(data)), dx
+	}
+
+	result := &FitResult{}
+	opts.Optimizer.Reset(len(x))
+	l, dx := objective(x)
+	for result.Iterations < opts.Iterations {
+		length := norm(dx)
+		if math.IsNaN(length) || math.IsInf(length, 0) {
+			break
+		}
+		if length <= opts.Tolerance {
+			result.Converged = true
+			break
+		}
+		opts.Optimizer.Step(objective, x, l, dx)
+		result.Iterations++
+		previous := l
+		l, dx = objective(x)
+		if math.Abs(previous-l) < opts.LossTolerance {
+			result.Converged = true
+			break
+		}
+	}
+	result.Values = make(map[string]float64, len(params))
+	for i, p := range params {
+		result.Values[p] = x[i]
+	}
+	result.Loss = l
+	return result, nil
+}
@@ -2,27 +2,31 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package feynman
 
 import (
-	"fmt"
+	"context"
 	"math"
 	"math/rand"
 	"sort"
 )
 
-//go:generate peg -switch -inline calculator.peg
-
+// Integrate searches for the antiderivative of an expression in x
 func Integrate(depth int, expression string) *Node {
-	calc := &Calculator[uint32]{Buffer: expression}
-	err := calc.Init()
+	a, err := IntegrateContext(context.Background(), depth, expression)
 	if err != nil {
 		panic(err)
 	}
-	if err := calc.Parse(); err != nil {
-		panic(err)
+	return a
+}
+
+// IntegrateContext searches for the antiderivative of an expression in x
+// until one is found or the context is done
+func IntegrateContext(ctx context.Context, depth int, expression string) (*Node, error) {
+	a, err := Parse(expression)
+	if err != nil {
+		return nil, err
 	}
-	a := calc.Tree()
 	seed := 1
 	values := []float64{.01, -.01, .1, -.1, 1, -1, 2, -2, 3, -3, 4, -4, 5, -5}
 	cache := make([]float64, len(values))
@@ -37,8 +41,11 @@ func Integrate(depth int, expression string) *Node {
 	for {
 		rng := rand.New(rand.NewSource(int64(seed)))
 		s := NewSource()
-		last := ""
+		var last *Node
 		for {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
 			r := s.Samples(depth, rng)
 			d := make([][]Element, len(values))
 			for j, v := range r {
@@ -69,13 +76,13 @@ func Integrate(depth int, expression string) *Node {
 				return r[i].Fitness < r[j].Fitness
 			})
 			if r[0].Fitness == 0 {
-				return r[0].Root
+				return r[0].Root, nil
 			}
 
-			if last == r[0].Root.String() {
+			if last.Equal(r[0].Root) {
 				break
 			}
-			last = r[0].Root.String()
+			last = r[0].Root
 
 			for k := range d {
 				sort.Slice(d[k], func(i, j int) bool {
@@ -114,77 +121,3 @@ func Integrate(depth int, expression string) *Node {
 		seed++
 	}
 }
-
-func main() {
-	calc := &Calculator[uint32]{Buffer: "(x3*x^(x1/x2))/x4"}
-	err := calc.Init()
-	if err != nil {
-		panic(err)
-	}
-	if err := calc.Parse(); err != nil {
-		panic(err)
-	}
-	a := calc.Tree()
-	b := a.Derivative(map[string]bool{"x": true})
-	expression := "(" + b.Simplify().String() + "- x5)^2"
-	fmt.Println(expression)
-	fmt.Println()
-	{
-		calc := &Calculator[uint32]{Buffer: expression}
-		err := calc.Init()
-		if err != nil {
-			panic(err)
-		}
-		if err := calc.Parse(); err != nil {
-			panic(err)
-		}
-		c := calc.Tree()
-		partials := [4]*Node{}
-		partials[0] = c.Derivative(map[string]bool{"x1": true}).Simplify()
-		partials[1] = c.Derivative(map[string]bool{"x2": true}).Simplify()
-		partials[2] = c.Derivative(map[string]bool{"x3": true}).Simplify()
-		partials[3] = c.Derivative(map[string]bool{"x4": true}).Simplify()
-		for _, v := range partials {
-			fmt.Println(v)
-		}
-
-		rng := rand.New(rand.NewSource(1))
-		values := map[string]float64{
-			"x":  3.0,
-			"x1": rng.Float64(),
-			"x2": rng.Float64(),
-			"x3": rng.Float64(),
-			"x4": rng.Float64(),
-			"x5": 9.0,
-		}
-		data := []float64{.001, .01, .1, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 100}
-		for i := 0; i < 8*1024; i++ {
-			dx := make([]float64, len(partials))
-			for _, datum := range data {
-				value := float64(datum)
-				values["x"] = value
-				values["x5"] = value * value
-				for j, v := range partials {
-					dx[j] += v.Calculate(values)
-				}
-			}
-			for j := range dx {
-				dx[j] /= float64(len(data))
-			}
-			sum := 0.0
-			for _, v := range dx {
-				sum += v * v
-			}
-			factor, length := 1.0, math.Sqrt(sum)
-			fmt.Println(length)
-			if length > 1 {
-				factor /= length
-			}
-			values["x1"] = values["x1"] + .0001*factor*dx[0]
-			values["x2"] = values["x2"] + .0001*factor*dx[1]
-			values["x3"] = values["x3"] + .0001*factor*dx[2]
-			values["x4"] = values["x4"] + .0001*factor*dx[3]
-		}
-		fmt.Println(values["x1"]/values["x2"], values["x3"]/values["x4"])
-	}
-}
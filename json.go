@@ -0,0 +1,154 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// jsonFloat is a float encoded as a json number, or as a string if it is not finite
+type jsonFloat float64
+
+// MarshalJSON encodes the float
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return json.Marshal(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the float
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	var v float64
+	if err := json.Unmarshal(data, &v); err == nil {
+		*f = jsonFloat(v)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*f = jsonFloat(v)
+	return nil
+}
+
+// jsonNode is the json encoding of a node
+type jsonNode struct {
+	Operation       string                     `json:"operation"`
+	Value           jsonFloat                  `json:"value,omitempty"`
+	Variable        string                     `json:"variable,omitempty"`
+	Children        []*jsonNode                `json:"children,omitempty"`
+	OperationSample *[OperationWidth]float64   `json:"operation_sample,omitempty"`
+	ValueSample     *[Bits][ValueWidth]float64 `json:"value_sample,omitempty"`
+}
+
+// arity returns the number of children an operation requires, sampled trees
+// may carry an unused right child
+func arity(o Operation) int {
+	switch o {
+	case OperationNoop, OperationNumber, OperationVariable, OperationPI, OperationImaginary, OperationNatural,
+		OperationPattern:
+		return 0
+	case OperationNegate, OperationCosine, OperationSine, OperationNaturalExponentiation,
+		OperationNaturalLogarithm, OperationSquareRoot, OperationTangent:
+		return 1
+	}
+	return 2
+}
+
+// MarshalNode encodes a tree as json, optionally including the search samples
+func MarshalNode(n *Node, samples bool) ([]byte, error) {
+	var process func(n *Node) *jsonNode
+	process = func(n *Node) *jsonNode {
+		if n == nil {
+			return nil
+		}
+		a := &jsonNode{
+			Operation: n.Operation.String(),
+			Value:     jsonFloat(n.Value),
+			Variable:  n.Variable,
+		}
+		if n.Right != nil {
+			a.Children = []*jsonNode{process(n.Left), process(n.Right)}
+		} else if n.Left != nil {
+			a.Children = []*jsonNode{process(n.Left)}
+		}
+		if samples {
+			if n.OperationSample != [OperationWidth]float64{} {
+				sample := n.OperationSample
+				a.OperationSample = &sample
+			}
+			if n.ValueSample != [Bits][ValueWidth]float64{} {
+				sample := n.ValueSample
+				a.ValueSample = &sample
+			}
+		}
+		return a
+	}
+	return json.Marshal(process(n))
+}
+
+// MarshalJSON encodes a tree as json without the search samples
+func (n *Node) MarshalJSON() ([]byte, error) {
+	return MarshalNode(n, false)
+}
+
+// UnmarshalJSON decodes a tree from json
+func (n *Node) UnmarshalJSON(data []byte) error {
+	a := jsonNode{}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	var process func(a *jsonNode) (*Node, error)
+	process = func(a *jsonNode) (*Node, error) {
+		if a == nil {
+			return nil, nil
+		}
+		operation, ok := ParseOperation(a.Operation)
+		if !ok {
+			return nil, fmt.Errorf("unknown operation %q", a.Operation)
+		}
+		if count := arity(operation); len(a.Children) < count || len(a.Children) > 2 {
+			return nil, fmt.Errorf("operation %s has %d children, expected %d", a.Operation, len(a.Children), count)
+		}
+		b := &Node{
+			Operation: operation,
+			Value:     float64(a.Value),
+			Variable:  a.Variable,
+		}
+		if a.OperationSample != nil {
+			b.OperationSample = *a.OperationSample
+		}
+		if a.ValueSample != nil {
+			b.ValueSample = *a.ValueSample
+		}
+		var err error
+		if len(a.Children) > 0 {
+			if b.Left, err = process(a.Children[0]); err != nil {
+				return nil, err
+			}
+		}
+		if len(a.Children) > 1 {
+			if b.Right, err = process(a.Children[1]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	b, err := process(&a)
+	if err != nil {
+		return err
+	}
+	*n = *b
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"strconv"
+	"strings"
+)
+
+// latexVariable formats a variable with trailing digits as a subscript
+func latexVariable(name string) string {
+	i := strings.IndexAny(name, "0123456789")
+	if i <= 0 {
+		return name
+	}
+	return name[:i] + "_{" + name[i:] + "}"
+}
+
+// LaTeX returns the LaTeX form of the equation
+func (n *Node) LaTeX() string {
+	var process func(n *Node) string
+	group := func(n *Node) string {
+		if n == nil {
+			return ""
+		}
+		switch n.Operation {
+		case OperationAdd, OperationSubtract, OperationNegate, OperationModulus:
+			return `\left(` + process(n) + `\right)`
+		}
+		return process(n)
+	}
+	process = func(n *Node) string {
+		if n == nil {
+			return ""
+		}
+		switch n.Operation {
+		case OperationNoop:
+			return process(n.Left) + " ? " + process(n.Right)
+		case OperationAdd:
+			return process(n.Left) + " + " + process(n.Right)
+		case OperationSubtract:
+			right := process(n.Right)
+			if n.Right != nil && (n.Right.Operation == OperationAdd || n.Right.Operation == OperationSubtract) {
+				right = `\left(` + right + `\right)`
+			}
+			return process(n.Left) + " - " + right
+		case OperationMultiply:
+			return group(n.Left) + ` \cdot ` + group(n.Right)
+		case OperationDivide:
+			return `\frac{` + process(n.Left) + "}{" + process(n.Right) + "}"
+		case OperationModulus:
+			return group(n.Left) + ` \bmod ` + group(n.Right)
+		case OperationExponentiation:
+			base := process(n.Left)
+			if n.Left != nil && !n.Left.Operation.IsTerminal() {
+				base = `\left(` + base + `\right)`
+			}
+			return "{" + base + "}^{" + process(n.Right) + "}"
+		case OperationNegate:
+			return "-" + group(n.Left)
+		case OperationVariable:
+			return latexVariable(n.Variable)
+		case OperationPattern:
+			return "?" + latexVariable(n.Variable)
+		case OperationImaginary:
+			return strconv.FormatFloat(n.Value, 'f', -1, 64) + "i"
+		case OperationNumber:
+			return strconv.FormatFloat(n.Value, 'f', -1, 64)
+		case OperationNotation:
+			if n.Left.Operation == OperationImaginary {
+				return strconv.FormatFloat(n.Left.Value, 'f', -1, 64) + ` \times 10^{` + process(n.Right) + "}i"
+			}
+			return process(n.Left) + ` \times 10^{` + process(n.Right) + "}"
+		case OperationNaturalExponentiation:
+			return "e^{" + process(n.Left) + "}"
+		case OperationNatural:
+			return "e"
+		case OperationPI:
+			return `\pi`
+		case OperationNaturalLogarithm:
+			return `\ln\left(` + process(n.Left) + `\right)`
+		case OperationSquareRoot:
+			return `\sqrt{` + process(n.Left) + "}"
+		case OperationCosine:
+			return `\cos\left(` + process(n.Left) + `\right)`
+		case OperationSine:
+			return `\sin\left(` + process(n.Left) + `\right)`
+		case OperationTangent:
+			return `\tan\left(` + process(n.Left) + `\right)`
+		case OperationDerivative:
+			return `\frac{d}{d` + process(n.Right) + `}\left(` + process(n.Left) + `\right)`
+		case OperationIntegral:
+			if n.Right != nil && n.Right.Operation == OperationBounds {
+				return `\int_{` + process(n.Right.Left) + "}^{" + process(n.Right.Right) + "} " +
+					process(n.Left) + `\, d` + latexVariable(n.Right.Variable)
+			}
+			return `\int ` + process(n.Left) + `\, d` + process(n.Right)
+		case OperationEquation:
+			return process(n.Left) + " = " + process(n.Right)
+		}
+		return ""
+	}
+	return process(n)
+}
@@ -0,0 +1,563 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// MaxLHopital is the maximum number of times L'Hôpital's rule is applied in
+// a row, and a quarter of the number of times in a limit
+const MaxLHopital = 8
+
+// MaxLimitNodes is the maximum size of the trees L'Hôpital's rule is applied
+// to
+const MaxLimitNodes = 1 << 9
+
+// ErrLimitUndetermined is returned when a limit can't be determined
+var ErrLimitUndetermined = errors.New("limit undetermined")
+
+// Direction is the direction a limit is approached from
+type Direction int
+
+const (
+	// DirectionBoth is a two sided limit
+	DirectionBoth Direction = iota
+	// DirectionLeft approaches the point from below
+	DirectionLeft
+	// DirectionRight approaches the point from above
+	DirectionRight
+)
+
+// LimitStatus is whether a limit exists
+type LimitStatus int
+
+const (
+	// LimitExists is a finite limit
+	LimitExists LimitStatus = iota
+	// LimitInfinite is a limit of +Inf or -Inf
+	LimitInfinite
+	// LimitDoesNotExist is a limit that doesn't exist, because the one sided
+	// limits differ or the expression oscillates
+	LimitDoesNotExist
+)
+
+// String returns the string form of the status
+func (s LimitStatus) String() string {
+	switch s {
+	case LimitExists:
+		return "exists"
+	case LimitInfinite:
+		return "infinite"
+	}
+	return "does not exist"
+}
+
+// LimitResult is the result of a limit
+type LimitResult struct {
+	Status LimitStatus
+	// Value is the limit if it exists or is infinite, exact if it is a
+	// rational or a rational multiple of pi
+	Value *Node
+}
+
+// extended is the limit of a subexpression as an extended real
+type extended struct {
+	value float64
+	// exists is false if the limit doesn't exist
+	exists bool
+	// bounded is true if a limit that doesn't exist stays bounded
+	bounded bool
+}
+
+// limiter computes the one sided limits of the subexpressions of a tree
+type limiter struct {
+	x     string
+	point float64
+	// side is -1 to approach from below and 1 from above
+	side  float64
+	depth int
+	steps int
+}
+
+// probe returns the value of a tree at distance h from the point on the
+// side of the limit, or at 1/h for infinite points
+func (l *limiter) probe(n *Node, h float64) float64 {
+	x := l.point + l.side*h
+	if math.IsInf(l.point, 0) {
+		x = -l.side / h
+	}
+	return n.Calculate(map[string]float64{l.x: x})
+}
+
+// sign returns the sign of a tree near the point, or 0 if it changes
+func (l *limiter) sign(n *Node) float64 {
+	sign := 0.0
+	for _, h := range []float64{1e-3, 1e-5, 1e-7} {
+		v := l.probe(n, h)
+		if v == 0 || math.IsNaN(v) || (sign != 0 && math.Signbit(v) != math.Signbit(sign)) {
+			return 0
+		}
+		sign = math.Copysign(1, v)
+	}
+	return sign
+}
+
+// rounded returns 0 for a limit within rounding error of 0 relative to the
+// magnitude of its operands, such as sin(pi) or pi - pi
+func rounded(v, scale float64) float64 {
+	if !math.IsInf(v, 0) && math.Abs(v) <= 1e-12*scale {
+		return math.Copysign(0, v)
+	}
+	return v
+}
+
+// constant is true if a tree doesn't depend on the variable
+func (l *limiter) constant(n *Node) bool {
+	for _, name := range n.FreeVariables() {
+		if name == l.x {
+			return false
+		}
+	}
+	return true
+}
+
+// derivative differentiates a tree with a DAG
+func (l *limiter) derivative(n *Node) *Node {
+	d := NewDAG()
+	return d.Node(d.Derivative(d.Add(n), l.x))
+}
+
+// nodes returns the number of nodes of a tree
+func nodes(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	count := 1
+	for _, child := range n.children() {
+		count += nodes(child)
+	}
+	return count
+}
+
+// order is the growth of e^(r|x|) |x|^p log(|x|)^q at infinity as (r, p, q)
+type order [3]float64
+
+// compare compares growth orders
+func (o order) compare(b order) int {
+	for i := range o {
+		if o[i] != b[i] {
+			if o[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// growth returns the growth order of a tree at an infinite point, it fails
+// when terms of the same order could cancel or for oscillating functions
+func (l *limiter) growth(n *Node) (order, bool) {
+	if l.constant(n) {
+		return order{}, true
+	}
+	switch n.Operation {
+	case OperationVariable:
+		return order{0, 1, 0}, true
+	case OperationNegate:
+		return l.growth(n.Left)
+	case OperationSquareRoot:
+		a, ok := l.growth(n.Left)
+		return order{a[0] / 2, a[1] / 2, a[2] / 2}, ok
+	case OperationMultiply, OperationDivide, OperationAdd, OperationSubtract:
+		a, ok := l.growth(n.Left)
+		if !ok {
+			return order{}, false
+		}
+		b, ok := l.growth(n.Right)
+		if !ok {
+			return order{}, false
+		}
+		switch n.Operation {
+		case OperationMultiply:
+			return order{a[0] + b[0], a[1] + b[1], a[2] + b[2]}, true
+		case OperationDivide:
+			return order{a[0] - b[0], a[1] - b[1], a[2] - b[2]}, true
+		}
+		switch a.compare(b) {
+		case 1:
+			return a, true
+		case -1:
+			return b, true
+		}
+		return order{}, false
+	case OperationExponentiation:
+		if l.constant(n.Right) {
+			a, ok := l.growth(n.Left)
+			k := n.Right.Calculate(nil)
+			return order{a[0] * k, a[1] * k, a[2] * k}, ok
+		}
+		if l.constant(n.Left) {
+			return l.growth(&Node{
+				Operation: OperationNaturalExponentiation,
+				Left: &Node{
+					Operation: OperationMultiply,
+					Left:      n.Right,
+					Right:     &Node{Operation: OperationNaturalLogarithm, Left: n.Left},
+				},
+			})
+		}
+	case OperationNaturalLogarithm:
+		a, ok := l.growth(n.Left)
+		switch {
+		case !ok:
+		case a[0] > 0:
+			return order{0, 1, 0}, true
+		case a[0] == 0 && a[1] > 0:
+			return order{0, 0, 1}, true
+		}
+	case OperationNaturalExponentiation:
+		// exp(c x + o(x)) with the rate c the limit of the exponent over x
+		a, ok := l.growth(n.Left)
+		if !ok || a.compare(order{0, 1, 0}) > 0 {
+			return order{}, false
+		}
+		if a.compare(order{}) <= 0 {
+			return order{}, true
+		}
+		if a.compare(order{0, 1, 0}) < 0 {
+			return order{}, false
+		}
+		c, err := l.limit(&Node{Operation: OperationDivide, Left: n.Left, Right: &Node{Operation: OperationVariable, Variable: l.x}})
+		if err != nil || !c.exists || math.IsInf(c.value, 0) || c.value == 0 {
+			return order{}, false
+		}
+		return order{c.value * math.Copysign(1, l.point), 0, 0}, true
+	}
+	return order{}, false
+}
+
+// quotient returns the limit of the indeterminate form num/den, by comparing
+// the leading terms of their series at finite points, their growth orders at
+// infinite points or by L'Hôpital's rule
+func (l *limiter) quotient(num, den *Node) (extended, error) {
+	if math.IsInf(l.point, 0) {
+		f, fok := l.growth(num)
+		g, gok := l.growth(den)
+		if fok && gok {
+			switch f.compare(g) {
+			case -1:
+				return extended{value: 0, exists: true}, nil
+			case 1:
+				sign := l.sign(&Node{Operation: OperationDivide, Left: num, Right: den})
+				if sign == 0 {
+					return extended{}, nil
+				}
+				return extended{value: math.Copysign(math.Inf(1), sign), exists: true}, nil
+			}
+		}
+	} else {
+		f, ferr := Series(num, l.x, l.point, MaxLHopital)
+		g, gerr := Series(den, l.x, l.point, MaxLHopital)
+		if ferr == nil && gerr == nil {
+			leading := func(c []float64) int {
+				scale := 0.0
+				for _, v := range c {
+					scale = math.Max(scale, math.Abs(v))
+				}
+				for k, v := range c {
+					if math.Abs(v) > 1e-12*scale {
+						return k
+					}
+				}
+				return -1
+			}
+			kf, kg := leading(f.Coefficients), leading(g.Coefficients)
+			switch {
+			case kf < 0 && kg < 0:
+			case kf < 0 || (kg >= 0 && kf > kg):
+				return extended{value: 0, exists: true}, nil
+			case kg >= 0 && kf == kg:
+				return extended{value: f.Coefficients[kf] / g.Coefficients[kg], exists: true}, nil
+			case kg >= 0:
+				v := f.Coefficients[kf] / g.Coefficients[kg]
+				if (kg-kf)%2 == 1 {
+					v *= l.side
+				}
+				return extended{value: math.Copysign(math.Inf(1), v), exists: true}, nil
+			}
+		}
+	}
+	l.depth++
+	l.steps++
+	defer func() {
+		l.depth--
+	}()
+	if l.depth > MaxLHopital || l.steps > 4*MaxLHopital {
+		return extended{}, fmt.Errorf("%w: %s / %s", ErrLimitUndetermined, num, den)
+	}
+	a := &Node{Operation: OperationDivide, Left: l.derivative(num), Right: l.derivative(den)}
+	if b, err := Together(a); err == nil {
+		a = b
+	}
+	if nodes(a) > MaxLimitNodes {
+		return extended{}, fmt.Errorf("%w: %s / %s", ErrLimitUndetermined, num, den)
+	}
+	return l.limit(a)
+}
+
+// indeterminate returns the limit of a node with an indeterminate form
+func (l *limiter) indeterminate(n *Node, a, b extended) (extended, error) {
+	one := &Node{Operation: OperationNumber, Value: 1}
+	reciprocal := func(n *Node) *Node {
+		return &Node{Operation: OperationDivide, Left: one, Right: n}
+	}
+	switch n.Operation {
+	case OperationDivide:
+		return l.quotient(n.Left, n.Right)
+	case OperationMultiply:
+		// 0 * inf as inf / (1 / 0), then as 0 / (1 / inf)
+		zero, infinite := n.Left, n.Right
+		if math.IsInf(a.value, 0) {
+			zero, infinite = infinite, zero
+		}
+		steps := l.steps
+		if c, err := l.quotient(infinite, reciprocal(zero)); err == nil {
+			return c, nil
+		}
+		l.steps = steps
+		return l.quotient(zero, reciprocal(infinite))
+	case OperationAdd, OperationSubtract:
+		// inf - inf over a common denominator, or as f * (1 - g/f)
+		if c, err := Together(n); err == nil && !c.Equal(n) {
+			return l.limit(c)
+		}
+		g := n.Right
+		if n.Operation == OperationAdd {
+			g = &Node{Operation: OperationNegate, Left: g}
+		}
+		ratio, err := l.limit(&Node{Operation: OperationDivide, Left: g, Right: n.Left})
+		if err != nil {
+			return extended{}, err
+		}
+		if !ratio.exists {
+			return extended{}, fmt.Errorf("%w: %s", ErrLimitUndetermined, n)
+		}
+		if ratio.value != 1 {
+			return extended{value: a.value * (1 - ratio.value), exists: true}, nil
+		}
+		// f - g = (1/g - 1/f) / (1/(f*g))
+		return l.quotient(&Node{
+			Operation: OperationSubtract,
+			Left:      reciprocal(g),
+			Right:     reciprocal(n.Left),
+		}, reciprocal(&Node{Operation: OperationMultiply, Left: n.Left, Right: g}))
+	case OperationExponentiation:
+		// f^g = exp(g * log(f))
+		c, err := l.limit(&Node{
+			Operation: OperationMultiply,
+			Left:      n.Right,
+			Right:     &Node{Operation: OperationNaturalLogarithm, Left: n.Left},
+		})
+		if err != nil {
+			return extended{}, err
+		}
+		return extended{value: math.Exp(c.value), exists: c.exists}, nil
+	}
+	return extended{}, fmt.Errorf("%w: %s", ErrLimitUndetermined, n)
+}
+
+// limit returns the one sided limit of a tree
+func (l *limiter) limit(n *Node) (extended, error) {
+	if n == nil {
+		return extended{}, errors.New("limit: missing operand")
+	}
+	if n.Operation == OperationVariable {
+		if math.IsInf(l.point, 0) || l.point != 0 {
+			return extended{value: l.point, exists: true}, nil
+		}
+		// signed zero so 1/x and log(x) go to the right infinity
+		return extended{value: math.Copysign(0, l.side), exists: true}, nil
+	}
+	children := n.children()
+	if len(children) == 0 || l.constant(n) {
+		return extended{value: n.Calculate(nil), exists: true}, nil
+	}
+	a, err := l.limit(children[0])
+	if err != nil {
+		return extended{}, err
+	}
+	t := Term{Operation: n.Operation, Value: n.Value}
+	if len(children) == 1 {
+		periodic := n.Operation == OperationSine || n.Operation == OperationCosine
+		if !a.exists {
+			bounded := periodic || (a.bounded && (n.Operation == OperationNegate ||
+				n.Operation == OperationNaturalExponentiation))
+			return extended{bounded: bounded}, nil
+		}
+		if periodic && math.IsInf(a.value, 0) {
+			return extended{bounded: true}, nil
+		}
+		v := calculate(t, a.value, 0)
+		if math.IsNaN(v) {
+			return extended{}, nil
+		}
+		if periodic || n.Operation == OperationTangent {
+			v = rounded(v, math.Max(1, math.Abs(a.value)))
+		}
+		return extended{value: v, exists: true}, nil
+	}
+	b, err := l.limit(children[1])
+	if err != nil {
+		return extended{}, err
+	}
+
+	if !a.exists || !b.exists {
+		switch n.Operation {
+		case OperationMultiply:
+			// a bounded factor times a factor that goes to 0
+			if (a.exists && a.value == 0 && b.bounded) || (b.exists && b.value == 0 && a.bounded) {
+				return extended{value: 0, exists: true}, nil
+			}
+		case OperationDivide:
+			if a.bounded && b.exists && math.IsInf(b.value, 0) {
+				return extended{value: 0, exists: true}, nil
+			}
+		case OperationAdd, OperationSubtract:
+			// a bounded term doesn't change an infinite limit
+			if a.exists && math.IsInf(a.value, 0) && b.bounded {
+				return a, nil
+			}
+			if b.exists && math.IsInf(b.value, 0) && a.bounded {
+				return extended{value: calculate(t, 0, b.value), exists: true}, nil
+			}
+		}
+		bounded := (a.bounded || (a.exists && !math.IsInf(a.value, 0))) &&
+			(b.bounded || (b.exists && !math.IsInf(b.value, 0))) &&
+			n.Operation != OperationDivide && n.Operation != OperationExponentiation
+		return extended{bounded: bounded}, nil
+	}
+
+	switch n.Operation {
+	case OperationMultiply:
+		// a constant 0 makes the product 0 even if the other factor is infinite
+		if (a.value == 0 && l.constant(children[0])) || (b.value == 0 && l.constant(children[1])) {
+			return extended{value: 0, exists: true}, nil
+		}
+	case OperationDivide:
+		if b.value == 0 && a.value != 0 && !math.IsNaN(a.value) {
+			sign := l.sign(children[1])
+			if sign == 0 {
+				return extended{}, nil
+			}
+			return extended{value: math.Copysign(math.Inf(1), a.value*sign), exists: true}, nil
+		}
+	case OperationExponentiation:
+		constantBase, constantExponent := l.constant(children[0]), l.constant(children[1])
+		if (a.value == 0 && b.value == 0 && !constantBase && !constantExponent) ||
+			(a.value == 1 && math.IsInf(b.value, 0) && !constantBase) ||
+			(math.IsInf(a.value, 0) && b.value == 0 && !constantExponent) {
+			return l.indeterminate(n, a, b)
+		}
+	}
+	v := calculate(t, a.value, b.value)
+	if n.Operation == OperationAdd || n.Operation == OperationSubtract {
+		v = rounded(v, math.Max(math.Abs(a.value), math.Abs(b.value)))
+	}
+	if math.IsNaN(v) {
+		if n.Operation == OperationModulus {
+			return extended{}, nil
+		}
+		return l.indeterminate(n, a, b)
+	}
+	return extended{value: v, exists: true}, nil
+}
+
+// limitNode converts a limit to a tree, exactly if it is a rational or a
+// rational multiple of pi or e
+func limitNode(v float64) *Node {
+	if r, ok := rationalize(v); ok {
+		if r.Sign() < 0 {
+			return &Node{Operation: OperationNegate, Left: rationalNode(new(big.Rat).Neg(r))}
+		}
+		return rationalNode(r)
+	}
+	for _, constant := range []*Node{
+		{Operation: OperationPI, Variable: "pi", Value: math.Pi},
+		{Operation: OperationNatural},
+	} {
+		r, ok := rationalize(v / constant.Calculate(nil))
+		if !ok {
+			continue
+		}
+		a := constant
+		if r.Cmp(big.NewRat(1, 1)) != 0 && r.Cmp(big.NewRat(-1, 1)) != 0 {
+			a = &Node{Operation: OperationMultiply, Left: rationalNode(new(big.Rat).Abs(r)), Right: constant}
+		}
+		if r.Sign() < 0 {
+			a = &Node{Operation: OperationNegate, Left: a}
+		}
+		return a
+	}
+	return &Node{Operation: OperationNumber, Value: v}
+}
+
+// Limit returns the limit of an expression of one variable x as x approaches
+// point, which may be infinite, from the given direction. Indeterminate forms
+// are resolved with the leading terms of series at finite points, the
+// dominance of exp over powers over log at infinite points, or L'Hôpital's
+// rule.
+func Limit(n *Node, x string, point float64, direction Direction) (*LimitResult, error) {
+	if n == nil {
+		return nil, errors.New("limit: nil expression")
+	}
+	if math.IsNaN(point) {
+		return nil, errors.New("limit: point is NaN")
+	}
+	for _, name := range n.FreeVariables() {
+		if name != x {
+			return nil, fmt.Errorf("limit: free variable %s", name)
+		}
+	}
+	sides := []float64{}
+	switch {
+	case math.IsInf(point, 1):
+		sides = append(sides, -1)
+	case math.IsInf(point, -1):
+		sides = append(sides, 1)
+	case direction == DirectionLeft:
+		sides = append(sides, -1)
+	case direction == DirectionRight:
+		sides = append(sides, 1)
+	default:
+		sides = append(sides, -1, 1)
+	}
+	limits := []extended{}
+	for _, side := range sides {
+		l := &limiter{x: x, point: point, side: side}
+		a, err := l.limit(n)
+		if err != nil {
+			return nil, err
+		}
+		if !a.exists {
+			return &LimitResult{Status: LimitDoesNotExist}, nil
+		}
+		limits = append(limits, a)
+	}
+	v := limits[0].value
+	for _, a := range limits[1:] {
+		if a.value != v && (math.IsInf(v, 0) || math.Abs(a.value-v) > 1e-9*math.Max(1, math.Abs(v))) {
+			return &LimitResult{Status: LimitDoesNotExist}, nil
+		}
+	}
+	if math.IsInf(v, 0) {
+		return &LimitResult{Status: LimitInfinite, Value: &Node{Operation: OperationNumber, Value: v}}, nil
+	}
+	return &LimitResult{Status: LimitExists, Value: limitNode(v)}, nil
+}
@@ -0,0 +1,309 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+const (
+	// DefaultLeastSquaresIterations is the default number of Levenberg-Marquardt iterations
+	DefaultLeastSquaresIterations = 256
+	// DefaultLeastSquaresTolerance is the default relative reduction in the sum of squares
+	DefaultLeastSquaresTolerance = 1e-12
+	// DefaultDamping is the default initial damping
+	DefaultDamping = 1e-3
+)
+
+// ErrSingular is returned when a matrix is singular
+var ErrSingular = errors.New("singular matrix")
+
+// LeastSquaresOptions are the options for LevenbergMarquardt
+type LeastSquaresOptions struct {
+	// Iterations is the maximum number of iterations
+	Iterations int
+	// Tolerance stops when the relative reduction in the sum of squares, the
+	// largest component of the gradient or the step relative to the parameters
+	// falls below it
+	Tolerance float64
+	// Damping is the initial damping factor
+	Damping float64
+	// Initial are the starting values of the parameters
+	Initial map[string]float64
+	// Rng initializes parameters missing from Initial
+	Rng *rand.Rand
+}
+
+// LeastSquaresResult is the result of LevenbergMarquardt
+type LeastSquaresResult struct {
+	// Values are the estimated parameter values
+	Values map[string]float64
+	// SumSquares is the final sum of squared residuals
+	SumSquares float64
+	// Covariance is the covariance matrix of the estimates in parameter order
+	Covariance [][]float64
+	// StandardErrors are the standard errors of the estimates
+	StandardErrors map[string]float64
+	// Iterations is the number of iterations performed
+	Iterations int
+	// Converged is true if the tolerance was reached or the parameters are at
+	// a stationary point
+	Converged bool
+}
+
+// Residual builds the residual model - target
+func Residual(model *Node, target string) *Node {
+	return &Node{
+		Operation: OperationSubtract,
+		Left:      model,
+		Right: &Node{
+			Operation: OperationVariable,
+			Variable:  target,
+		},
+	}
+}
+
+// solve solves a x = b with gaussian elimination and partial pivoting
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n+1)
+		copy(m[i], a[i])
+		m[i][n] = b[i]
+	}
+	for i := 0; i < n; i++ {
+		pivot := i
+		for j := i + 1; j < n; j++ {
+			if math.Abs(m[j][i]) > math.Abs(m[pivot][i]) {
+				pivot = j
+			}
+		}
+		if m[pivot][i] == 0 || math.IsNaN(m[pivot][i]) {
+			return nil, ErrSingular
+		}
+		m[i], m[pivot] = m[pivot], m[i]
+		for j := i + 1; j < n; j++ {
+			factor := m[j][i] / m[i][i]
+			for k := i; k <= n; k++ {
+				m[j][k] -= factor * m[i][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := m[i][n]
+		for j := i + 1; j < n; j++ {
+			sum -= m[i][j] * x[j]
+		}
+		x[i] = sum / m[i][i]
+	}
+	return x, nil
+}
+
+// invert inverts a matrix
+func invert(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	inverse := make([][]float64, n)
+	for i := range inverse {
+		inverse[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		e := make([]float64, n)
+		e[j] = 1
+		column, err := solve(a, e)
+		if err != nil {
+			return nil, err
+		}
+		for i := range column {
+			inverse[i][j] = column[i]
+		}
+	}
+	return inverse, nil
+}
+
+// LevenbergMarquardt minimizes the sum of squares of the residual expressions
+// evaluated at each datum, using a symbolic jacobian. If data is empty the
+// residuals are evaluated once with only the parameters bound.
+// https://en.wikipedia.org/wiki/Levenberg%E2%80%93Marquardt_algorithm
+func LevenbergMarquardt(residuals []*Node, params []string, data Dataset, opts LeastSquaresOptions) (*LeastSquaresResult, error) {
+	if len(residuals) == 0 {
+		return nil, errors.New("least squares: no residuals")
+	}
+	if len(params) == 0 {
+		return nil, errors.New("least squares: no parameters")
+	}
+	if len(data) == 0 {
+		data = Dataset{{}}
+	}
+	if opts.Iterations == 0 {
+		opts.Iterations = DefaultLeastSquaresIterations
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultLeastSquaresTolerance
+	}
+	if opts.Damping == 0 {
+		opts.Damping = DefaultDamping
+	}
+	if opts.Rng == nil {
+		opts.Rng = rand.New(rand.NewSource(1))
+	}
+
+	jacobian := make([][]*Node, len(residuals))
+	for i, r := range residuals {
+		jacobian[i] = make([]*Node, len(params))
+		for j, p := range params {
+			jacobian[i][j] = r.Derivative(map[string]bool{p: true}).Simplify()
+		}
+	}
+
+	x := make([]float64, len(params))
+	for i, p := range params {
+		if v, ok := opts.Initial[p]; ok {
+			x[i] = v
+		} else {
+			x[i] = opts.Rng.Float64()
+		}
+	}
+	bind := func(datum map[string]float64, x []float64) map[string]float64 {
+		values := make(map[string]float64, len(datum)+len(x))
+		for k, v := range datum {
+			values[k] = v
+		}
+		for i, p := range params {
+			values[p] = x[i]
+		}
+		return values
+	}
+	sumSquares := func(x []float64) float64 {
+		sum := 0.0
+		for _, datum := range data {
+			values := bind(datum, x)
+			for _, r := range residuals {
+				v := r.Calculate(values)
+				sum += v * v
+			}
+		}
+		return sum
+	}
+	// normal computes the normal equations J^T J and J^T r
+	normal := func(x []float64) ([][]float64, []float64) {
+		a, g := make([][]float64, len(params)), make([]float64, len(params))
+		for i := range a {
+			a[i] = make([]float64, len(params))
+		}
+		row := make([]float64, len(params))
+		for _, datum := range data {
+			values := bind(datum, x)
+			for i, r := range residuals {
+				v := r.Calculate(values)
+				for j := range row {
+					row[j] = jacobian[i][j].Calculate(values)
+				}
+				for j := range row {
+					g[j] += row[j] * v
+					for k := range row {
+						a[j][k] += row[j] * row[k]
+					}
+				}
+			}
+		}
+		return a, g
+	}
+
+	// norm is the largest absolute component of a vector
+	norm := func(v []float64) float64 {
+		max := 0.0
+		for _, u := range v {
+			max = math.Max(max, math.Abs(u))
+		}
+		return max
+	}
+
+	result := &LeastSquaresResult{}
+	lambda, s := opts.Damping, sumSquares(x)
+	a, g := normal(x)
+	if s == 0 || norm(g) <= opts.Tolerance {
+		result.Converged = true
+	}
+	y := make([]float64, len(x))
+	for result.Iterations < opts.Iterations && !result.Converged {
+		result.Iterations++
+		damped := make([][]float64, len(a))
+		for i := range a {
+			damped[i] = make([]float64, len(a))
+			copy(damped[i], a[i])
+			d := a[i][i]
+			if d == 0 {
+				d = 1
+			}
+			damped[i][i] += lambda * d
+		}
+		b := make([]float64, len(g))
+		for i, v := range g {
+			b[i] = -v
+		}
+		delta, err := solve(damped, b)
+		if err != nil {
+			lambda *= 10
+			continue
+		}
+		for i := range x {
+			y[i] = x[i] + delta[i]
+		}
+		next := sumSquares(y)
+		if next < s {
+			if s-next <= opts.Tolerance*s || norm(delta) <= opts.Tolerance*(norm(x)+opts.Tolerance) {
+				result.Converged = true
+			}
+			copy(x, y)
+			s = next
+			a, g = normal(x)
+			lambda /= 10
+			if s == 0 || norm(g) <= opts.Tolerance {
+				result.Converged = true
+			}
+		} else {
+			lambda *= 10
+			if lambda > 1e16 {
+				// the damping blew up without reducing the sum of squares, which
+				// is rounding error at a stationary point and a stall otherwise
+				result.Converged = norm(g) <= math.Sqrt(opts.Tolerance)*(1+s)
+				break
+			}
+		}
+	}
+
+	result.Values = make(map[string]float64, len(params))
+	for i, p := range params {
+		result.Values[p] = x[i]
+	}
+	result.SumSquares = s
+	result.StandardErrors = make(map[string]float64, len(params))
+	m := len(data) * len(residuals)
+	variance := s
+	if m > len(params) {
+		variance /= float64(m - len(params))
+	}
+	inverse, err := invert(a)
+	if err != nil {
+		for _, p := range params {
+			result.StandardErrors[p] = math.NaN()
+		}
+		return result, nil
+	}
+	result.Covariance = inverse
+	for i := range inverse {
+		for j := range inverse[i] {
+			inverse[i][j] *= variance
+		}
+	}
+	for i, p := range params {
+		result.StandardErrors[p] = math.Sqrt(inverse[i][i])
+	}
+	return result, nil
+}
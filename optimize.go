@@ -0,0 +1,244 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"math"
+)
+
+// Objective computes the loss and the gradient of the loss at x
+type Objective func(x []float64) (float64, []float64)
+
+// Optimizer minimizes an objective one step at a time
+type Optimizer interface {
+	// Reset clears the state of the optimizer for n parameters
+	Reset(n int)
+	// Step updates x in place given the loss and gradient at x
+	Step(objective Objective, x []float64, loss float64, gradient []float64)
+}
+
+// dot computes the dot product of two vectors
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// norm computes the length of a vector
+func norm(a []float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// LineSearch finds a step along direction satisfying the Armijo condition
+// using backtracking from the initial step
+func LineSearch(objective Objective, x []float64, loss float64, gradient, direction []float64, step float64) float64 {
+	const (
+		c      = 1e-4
+		shrink = .5
+	)
+	slope := dot(gradient, direction)
+	y := make([]float64, len(x))
+	for range 64 {
+		for i := range x {
+			y[i] = x[i] + step*direction[i]
+		}
+		l, _ := objective(y)
+		if !math.IsNaN(l) && l <= loss+c*step*slope {
+			return step
+		}
+		step *= shrink
+	}
+	return 0
+}
+
+// GradientDescent is plain gradient descent
+type GradientDescent struct {
+	// Rate is the learning rate
+	Rate float64
+	// Normalize clips the gradient to unit length
+	Normalize bool
+	// LineSearch searches for the step size starting at Rate
+	LineSearch bool
+}
+
+// Reset resets gradient descent
+func (g *GradientDescent) Reset(n int) {}
+
+// Step takes a gradient descent step
+func (g *GradientDescent) Step(objective Objective, x []float64, loss float64, gradient []float64) {
+	direction := make([]float64, len(gradient))
+	factor := 1.0
+	if length := norm(gradient); g.Normalize && length > 1 {
+		factor /= length
+	}
+	for i, v := range gradient {
+		direction[i] = -factor * v
+	}
+	step := g.Rate
+	if g.LineSearch {
+		step = LineSearch(objective, x, loss, gradient, direction, step)
+	}
+	for i := range x {
+		x[i] += step * direction[i]
+	}
+}
+
+// Momentum is gradient descent with momentum
+type Momentum struct {
+	// Rate is the learning rate
+	Rate float64
+	// Beta is the decay of the velocity
+	Beta     float64
+	velocity []float64
+}
+
+// Reset resets the velocity
+func (m *Momentum) Reset(n int) {
+	m.velocity = make([]float64, n)
+}
+
+// Step takes a momentum step
+func (m *Momentum) Step(objective Objective, x []float64, loss float64, gradient []float64) {
+	if len(m.velocity) != len(x) {
+		m.Reset(len(x))
+	}
+	for i, v := range gradient {
+		m.velocity[i] = m.Beta*m.velocity[i] - m.Rate*v
+		x[i] += m.velocity[i]
+	}
+}
+
+// Adam is the adam optimizer
+// https://arxiv.org/abs/1412.6980
+type Adam struct {
+	// Rate is the learning rate
+	Rate float64
+	// Beta1 is the decay of the first moment
+	Beta1 float64
+	// Beta2 is the decay of the second moment
+	Beta2 float64
+	// Epsilon prevents division by zero
+	Epsilon float64
+	m, v    []float64
+	t       int
+}
+
+// NewAdam creates a new adam optimizer with the default parameters
+func NewAdam(rate float64) *Adam {
+	return &Adam{
+		Rate:    rate,
+		Beta1:   .9,
+		Beta2:   .999,
+		Epsilon: 1e-8,
+	}
+}
+
+// Reset resets the moments
+func (a *Adam) Reset(n int) {
+	a.m = make([]float64, n)
+	a.v = make([]float64, n)
+	a.t = 0
+}
+
+// Step takes an adam step
+func (a *Adam) Step(objective Objective, x []float64, loss float64, gradient []float64) {
+	if len(a.m) != len(x) || len(a.v) != len(x) {
+		a.Reset(len(x))
+	}
+	a.t++
+	b1, b2 := 1-math.Pow(a.Beta1, float64(a.t)), 1-math.Pow(a.Beta2, float64(a.t))
+	for i, g := range gradient {
+		a.m[i] = a.Beta1*a.m[i] + (1-a.Beta1)*g
+		a.v[i] = a.Beta2*a.v[i] + (1-a.Beta2)*g*g
+		mhat, vhat := a.m[i]/b1, a.v[i]/b2
+		x[i] -= a.Rate * mhat / (math.Sqrt(vhat) + a.Epsilon)
+	}
+}
+
+// LBFGS is the limited memory BFGS quasi-newton method
+// https://en.wikipedia.org/wiki/Limited-memory_BFGS
+type LBFGS struct {
+	// Memory is the number of corrections to keep
+	Memory int
+	s, y   [][]float64
+	x, g   []float64
+}
+
+// Reset clears the history
+func (l *LBFGS) Reset(n int) {
+	if l.Memory == 0 {
+		l.Memory = 8
+	}
+	l.s, l.y = nil, nil
+	l.x, l.g = nil, nil
+}
+
+// Step takes a quasi-newton step with a line search
+func (l *LBFGS) Step(objective Objective, x []float64, loss float64, gradient []float64) {
+	if l.Memory == 0 || (l.x != nil && len(l.x) != len(x)) {
+		l.Reset(len(x))
+	}
+	if l.x != nil {
+		s, y := make([]float64, len(x)), make([]float64, len(x))
+		for i := range x {
+			s[i] = x[i] - l.x[i]
+			y[i] = gradient[i] - l.g[i]
+		}
+		if dot(s, y) > 1e-12 {
+			l.s, l.y = append(l.s, s), append(l.y, y)
+			if len(l.s) > l.Memory {
+				l.s, l.y = l.s[1:], l.y[1:]
+			}
+		}
+	}
+
+	q := make([]float64, len(gradient))
+	copy(q, gradient)
+	alpha := make([]float64, len(l.s))
+	for i := len(l.s) - 1; i >= 0; i-- {
+		alpha[i] = dot(l.s[i], q) / dot(l.y[i], l.s[i])
+		for j := range q {
+			q[j] -= alpha[i] * l.y[i][j]
+		}
+	}
+	if k := len(l.s) - 1; k >= 0 {
+		gamma := dot(l.s[k], l.y[k]) / dot(l.y[k], l.y[k])
+		for j := range q {
+			q[j] *= gamma
+		}
+	}
+	for i := range l.s {
+		beta := dot(l.y[i], q) / dot(l.y[i], l.s[i])
+		for j := range q {
+			q[j] += l.s[i][j] * (alpha[i] - beta)
+		}
+	}
+	direction := q
+	for i := range direction {
+		direction[i] = -direction[i]
+	}
+	step := 1.0
+	if dot(gradient, direction) >= 0 {
+		l.s, l.y = nil, nil
+		for i, v := range gradient {
+			direction[i] = -v
+		}
+	}
+	if len(l.s) == 0 {
+		if length := norm(gradient); length > 1 {
+			step /= length
+		}
+	}
+	step = LineSearch(objective, x, loss, gradient, direction, step)
+
+	l.x, l.g = make([]float64, len(x)), make([]float64, len(gradient))
+	copy(l.x, x)
+	copy(l.g, gradient)
+	for i := range x {
+		x[i] += step * direction[i]
+	}
+}
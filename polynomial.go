@@ -0,0 +1,633 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxPolynomialExponent is the largest exponent expanded by NewPolynomial
+const MaxPolynomialExponent = 1024
+
+// ErrNotPolynomial is returned when an expression isn't a polynomial
+var ErrNotPolynomial = errors.New("not a polynomial")
+
+// Monomial is a rational coefficient times variables raised to positive
+// integer powers
+type Monomial struct {
+	Coefficient *big.Rat
+	Powers      map[string]int
+}
+
+// variables returns the sorted variables of the monomial
+func (m Monomial) variables() []string {
+	names := make([]string, 0, len(m.Powers))
+	for name := range m.Powers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// key returns the key of the powers of the monomial
+func (m Monomial) key() string {
+	parts := []string{}
+	for _, name := range m.variables() {
+		parts = append(parts, name+"^"+strconv.Itoa(m.Powers[name]))
+	}
+	return strings.Join(parts, "*")
+}
+
+// Degree returns the total degree of the monomial
+func (m Monomial) Degree() int {
+	degree := 0
+	for _, power := range m.Powers {
+		degree += power
+	}
+	return degree
+}
+
+// Polynomial is a multivariate polynomial with rational coefficients
+type Polynomial struct {
+	terms map[string]Monomial
+}
+
+// NewConstant returns a constant polynomial
+func NewConstant(c *big.Rat) *Polynomial {
+	p := &Polynomial{terms: make(map[string]Monomial)}
+	p.add(Monomial{Coefficient: c, Powers: map[string]int{}})
+	return p
+}
+
+// NewVariable returns the polynomial of a variable
+func NewVariable(name string) *Polynomial {
+	p := &Polynomial{terms: make(map[string]Monomial)}
+	p.add(Monomial{Coefficient: big.NewRat(1, 1), Powers: map[string]int{name: 1}})
+	return p
+}
+
+// add adds a monomial to the polynomial in place
+func (p *Polynomial) add(m Monomial) {
+	key := m.key()
+	c := new(big.Rat).Set(m.Coefficient)
+	if a, ok := p.terms[key]; ok {
+		c.Add(c, a.Coefficient)
+	}
+	if c.Sign() == 0 {
+		delete(p.terms, key)
+		return
+	}
+	powers := make(map[string]int, len(m.Powers))
+	for name, power := range m.Powers {
+		powers[name] = power
+	}
+	p.terms[key] = Monomial{Coefficient: c, Powers: powers}
+}
+
+// NewPolynomial converts a tree to a polynomial, the tree may only contain
+// numbers, variables, +, -, *, negation, division by constants and powers
+// with constant non negative integer exponents
+func NewPolynomial(n *Node) (*Polynomial, error) {
+	var process func(n *Node) (*Polynomial, error)
+	process = func(n *Node) (*Polynomial, error) {
+		if n == nil {
+			return nil, fmt.Errorf("%w: missing operand", ErrNotPolynomial)
+		}
+		switch n.Operation {
+		case OperationNumber:
+			c := new(big.Rat)
+			if c.SetFloat64(n.Value) == nil {
+				return nil, fmt.Errorf("%w: %v isn't finite", ErrNotPolynomial, n.Value)
+			}
+			return NewConstant(c), nil
+		case OperationVariable:
+			return NewVariable(n.Variable), nil
+		case OperationNegate:
+			a, err := process(n.Left)
+			if err != nil {
+				return nil, err
+			}
+			return a.Scale(big.NewRat(-1, 1)), nil
+		case OperationAdd, OperationSubtract, OperationMultiply, OperationDivide, OperationExponentiation:
+			a, err := process(n.Left)
+			if err != nil {
+				return nil, err
+			}
+			b, err := process(n.Right)
+			if err != nil {
+				return nil, err
+			}
+			switch n.Operation {
+			case OperationAdd:
+				return a.Add(b), nil
+			case OperationSubtract:
+				return a.Sub(b), nil
+			case OperationMultiply:
+				return a.Mul(b), nil
+			case OperationDivide:
+				c, ok := b.Constant()
+				if !ok || c.Sign() == 0 {
+					return nil, fmt.Errorf("%w: division by %s", ErrNotPolynomial, n.Right)
+				}
+				return a.Scale(new(big.Rat).Inv(c)), nil
+			}
+			c, ok := b.Constant()
+			if !ok || !c.IsInt() || c.Sign() < 0 || c.Num().Cmp(big.NewInt(MaxPolynomialExponent)) > 0 {
+				return nil, fmt.Errorf("%w: exponent %s", ErrNotPolynomial, n.Right)
+			}
+			return a.Pow(int(c.Num().Int64())), nil
+		}
+		return nil, fmt.Errorf("%w: operation %s", ErrNotPolynomial, n.Operation)
+	}
+	return process(n)
+}
+
+// Terms returns the monomials of the polynomial by decreasing total degree
+// and then lexicographically by powers
+func (p *Polynomial) Terms() []Monomial {
+	terms := make([]Monomial, 0, len(p.terms))
+	for _, m := range p.terms {
+		terms = append(terms, m)
+	}
+	variables := p.Variables()
+	sort.Slice(terms, func(i, j int) bool {
+		a, b := terms[i], terms[j]
+		if a.Degree() != b.Degree() {
+			return a.Degree() > b.Degree()
+		}
+		for _, name := range variables {
+			if a.Powers[name] != b.Powers[name] {
+				return a.Powers[name] > b.Powers[name]
+			}
+		}
+		return false
+	})
+	return terms
+}
+
+// Variables returns the sorted variables of the polynomial
+func (p *Polynomial) Variables() []string {
+	seen := make(map[string]bool)
+	for _, m := range p.terms {
+		for name := range m.Powers {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsZero returns if the polynomial is zero
+func (p *Polynomial) IsZero() bool {
+	return len(p.terms) == 0
+}
+
+// Constant returns the value of a constant polynomial
+func (p *Polynomial) Constant() (*big.Rat, bool) {
+	switch len(p.terms) {
+	case 0:
+		return new(big.Rat), true
+	case 1:
+		if m, ok := p.terms[""]; ok {
+			return new(big.Rat).Set(m.Coefficient), true
+		}
+	}
+	return nil, false
+}
+
+// Coefficient returns the coefficient of the monomial with powers
+func (p *Polynomial) Coefficient(powers map[string]int) *big.Rat {
+	if m, ok := p.terms[(Monomial{Powers: powers}).key()]; ok {
+		return new(big.Rat).Set(m.Coefficient)
+	}
+	return new(big.Rat)
+}
+
+// Equal returns if two polynomials are equal
+func (p *Polynomial) Equal(q *Polynomial) bool {
+	if len(p.terms) != len(q.terms) {
+		return false
+	}
+	for key, m := range p.terms {
+		b, ok := q.terms[key]
+		if !ok || m.Coefficient.Cmp(b.Coefficient) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns the sum of two polynomials
+func (p *Polynomial) Add(q *Polynomial) *Polynomial {
+	a := &Polynomial{terms: make(map[string]Monomial, len(p.terms)+len(q.terms))}
+	for _, m := range p.terms {
+		a.add(m)
+	}
+	for _, m := range q.terms {
+		a.add(m)
+	}
+	return a
+}
+
+// Scale returns the polynomial multiplied by a constant
+func (p *Polynomial) Scale(c *big.Rat) *Polynomial {
+	a := &Polynomial{terms: make(map[string]Monomial, len(p.terms))}
+	for _, m := range p.terms {
+		a.add(Monomial{Coefficient: new(big.Rat).Mul(m.Coefficient, c), Powers: m.Powers})
+	}
+	return a
+}
+
+// Sub returns the difference of two polynomials
+func (p *Polynomial) Sub(q *Polynomial) *Polynomial {
+	return p.Add(q.Scale(big.NewRat(-1, 1)))
+}
+
+// Mul returns the product of two polynomials
+func (p *Polynomial) Mul(q *Polynomial) *Polynomial {
+	a := &Polynomial{terms: make(map[string]Monomial)}
+	for _, x := range p.terms {
+		for _, y := range q.terms {
+			powers := make(map[string]int, len(x.Powers)+len(y.Powers))
+			for name, power := range x.Powers {
+				powers[name] += power
+			}
+			for name, power := range y.Powers {
+				powers[name] += power
+			}
+			a.add(Monomial{Coefficient: new(big.Rat).Mul(x.Coefficient, y.Coefficient), Powers: powers})
+		}
+	}
+	return a
+}
+
+// Pow returns the polynomial raised to a non negative integer power
+func (p *Polynomial) Pow(k int) *Polynomial {
+	a, b := NewConstant(big.NewRat(1, 1)), p
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			a = a.Mul(b)
+		}
+		if k > 1 {
+			b = b.Mul(b)
+		}
+	}
+	return a
+}
+
+// Degree returns the degree of the polynomial in a variable, -1 for zero
+func (p *Polynomial) Degree(x string) int {
+	if p.IsZero() {
+		return -1
+	}
+	degree := 0
+	for _, m := range p.terms {
+		if m.Powers[x] > degree {
+			degree = m.Powers[x]
+		}
+	}
+	return degree
+}
+
+// Coefficients returns the coefficients of the polynomial as a polynomial
+// in a variable, indexed by power
+func (p *Polynomial) Coefficients(x string) []*Polynomial {
+	coefficients := make([]*Polynomial, p.Degree(x)+1)
+	for i := range coefficients {
+		coefficients[i] = &Polynomial{terms: make(map[string]Monomial)}
+	}
+	for _, m := range p.terms {
+		powers := make(map[string]int, len(m.Powers))
+		for name, power := range m.Powers {
+			if name != x {
+				powers[name] = power
+			}
+		}
+		coefficients[m.Powers[x]].add(Monomial{Coefficient: m.Coefficient, Powers: powers})
+	}
+	return coefficients
+}
+
+// rationalNode converts a rational to a tree
+func rationalNode(c *big.Rat) *Node {
+	number := func(x *big.Int) *Node {
+		v, _ := new(big.Float).SetInt(x).Float64()
+		return &Node{Operation: OperationNumber, Value: v}
+	}
+	if c.IsInt() {
+		return number(c.Num())
+	}
+	return &Node{
+		Operation: OperationDivide,
+		Left:      number(c.Num()),
+		Right:     number(c.Denom()),
+	}
+}
+
+// power returns a variable raised to a power as a tree
+func power(x string, k int) *Node {
+	a := &Node{Operation: OperationVariable, Variable: x}
+	if k == 1 {
+		return a
+	}
+	return &Node{
+		Operation: OperationExponentiation,
+		Left:      a,
+		Right:     &Node{Operation: OperationNumber, Value: float64(k)},
+	}
+}
+
+// product returns the product of factors, or 1 if there are none
+func product(factors []*Node) *Node {
+	if len(factors) == 0 {
+		return &Node{Operation: OperationNumber, Value: 1}
+	}
+	a := factors[0]
+	for _, factor := range factors[1:] {
+		a = &Node{Operation: OperationMultiply, Left: a, Right: factor}
+	}
+	return a
+}
+
+// sumTerms returns the sum of terms with their signs, subtracting negative
+// terms after the first
+func sumTerms(terms []*Node, negative []bool) *Node {
+	if len(terms) == 0 {
+		return &Node{Operation: OperationNumber, Value: 0}
+	}
+	a := terms[0]
+	if negative[0] {
+		a = &Node{Operation: OperationNegate, Left: a}
+	}
+	for i, term := range terms[1:] {
+		operation := OperationAdd
+		if negative[i+1] {
+			operation = OperationSubtract
+		}
+		a = &Node{Operation: operation, Left: a, Right: term}
+	}
+	return a
+}
+
+// Node converts the polynomial to a tree in standard form
+func (p *Polynomial) Node() *Node {
+	terms, negative := []*Node{}, []bool{}
+	for _, m := range p.Terms() {
+		c := new(big.Rat).Abs(m.Coefficient)
+		factors := []*Node{}
+		if len(m.Powers) == 0 || c.Cmp(big.NewRat(1, 1)) != 0 {
+			factors = append(factors, rationalNode(c))
+		}
+		for _, name := range m.variables() {
+			factors = append(factors, power(name, m.Powers[name]))
+		}
+		terms = append(terms, product(factors))
+		negative = append(negative, m.Coefficient.Sign() < 0)
+	}
+	return sumTerms(terms, negative)
+}
+
+// String returns the string form of the polynomial
+func (p *Polynomial) String() string {
+	return p.Node().String()
+}
+
+// FactorPower is a factor of a polynomial raised to a power
+type FactorPower struct {
+	Base     *Polynomial
+	Exponent int
+}
+
+// Factorization is a polynomial factored into a rational content and
+// factors with integer coefficients
+type Factorization struct {
+	Content *big.Rat
+	Factors []FactorPower
+	// Partial is true if a factor may still be reducible because the search
+	// for its factors exceeded its limits
+	Partial bool
+}
+
+// Node converts the factorization to a tree
+func (f *Factorization) Node() *Node {
+	factors := []*Node{}
+	negative := f.Content.Sign() < 0
+	c := new(big.Rat).Abs(f.Content)
+	if c.Cmp(big.NewRat(1, 1)) != 0 || len(f.Factors) == 0 {
+		factors = append(factors, rationalNode(c))
+	}
+	for _, factor := range f.Factors {
+		a := factor.Base.Node()
+		if factor.Exponent != 1 {
+			a = &Node{
+				Operation: OperationExponentiation,
+				Left:      a,
+				Right:     &Node{Operation: OperationNumber, Value: float64(factor.Exponent)},
+			}
+		}
+		factors = append(factors, a)
+	}
+	a := product(factors)
+	if negative {
+		a = &Node{Operation: OperationNegate, Left: a}
+	}
+	return a
+}
+
+// Polynomial multiplies out the factorization
+func (f *Factorization) Polynomial() *Polynomial {
+	a := NewConstant(f.Content)
+	for _, factor := range f.Factors {
+		a = a.Mul(factor.Base.Pow(factor.Exponent))
+	}
+	return a
+}
+
+// content returns the rational content of a polynomial: the gcd of the
+// numerators over the lcm of the denominators, with the sign of the leading
+// term
+func (p *Polynomial) content() *big.Rat {
+	terms := p.Terms()
+	if len(terms) == 0 {
+		return big.NewRat(1, 1)
+	}
+	numerator, denominator := new(big.Int), big.NewInt(1)
+	for _, m := range terms {
+		numerator.GCD(nil, nil, numerator, new(big.Int).Abs(m.Coefficient.Num()))
+		gcd := new(big.Int).GCD(nil, nil, denominator, m.Coefficient.Denom())
+		denominator.Mul(denominator, m.Coefficient.Denom())
+		denominator.Quo(denominator, gcd)
+	}
+	c := new(big.Rat).SetFrac(numerator, denominator)
+	if terms[0].Coefficient.Sign() < 0 {
+		c.Neg(c)
+	}
+	return c
+}
+
+// maxRootSearch is the largest coefficient whose divisors are searched for
+// rational roots
+const maxRootSearch = 1 << 40
+
+// divisors returns the positive divisors of a non zero integer
+func divisors(a *big.Int) []*big.Int {
+	n := new(big.Int).Abs(a).Uint64()
+	small, large := []*big.Int{}, []*big.Int{}
+	for i := uint64(1); i*i <= n; i++ {
+		if n%i == 0 {
+			small = append(small, new(big.Int).SetUint64(i))
+			if i*i != n {
+				large = append(large, new(big.Int).SetUint64(n/i))
+			}
+		}
+	}
+	for i := len(large) - 1; i >= 0; i-- {
+		small = append(small, large[i])
+	}
+	return small
+}
+
+// evaluate computes a univariate polynomial given by its coefficients at a
+// rational point
+func evaluate(coefficients []*big.Rat, x *big.Rat) *big.Rat {
+	a := new(big.Rat)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		a.Mul(a, x)
+		a.Add(a, coefficients[i])
+	}
+	return a
+}
+
+// Factor factors the polynomial over the rationals into its content, the
+// monomial common to its terms and irreducible factors with integer
+// coefficients. Univariate polynomials are made square free, then linear
+// factors are found from their rational roots and the others by Kronecker's
+// method. Multivariate polynomials are factored through a Kronecker
+// substitution.
+func (p *Polynomial) Factor() *Factorization {
+	f := &Factorization{Content: p.content()}
+	if p.IsZero() {
+		f.Content = new(big.Rat)
+		return f
+	}
+	primitive := p.Scale(new(big.Rat).Inv(f.Content))
+
+	common := map[string]int{}
+	for i, m := range primitive.Terms() {
+		for _, name := range primitive.Variables() {
+			if i == 0 || m.Powers[name] < common[name] {
+				common[name] = m.Powers[name]
+			}
+		}
+	}
+	divided := &Polynomial{terms: make(map[string]Monomial)}
+	for _, m := range primitive.terms {
+		powers := make(map[string]int)
+		for name, power := range m.Powers {
+			if power -= common[name]; power > 0 {
+				powers[name] = power
+			}
+		}
+		divided.add(Monomial{Coefficient: m.Coefficient, Powers: powers})
+	}
+	for _, name := range primitive.Variables() {
+		if common[name] > 0 {
+			f.Factors = append(f.Factors, FactorPower{Base: NewVariable(name), Exponent: common[name]})
+		}
+	}
+
+	factors := []FactorPower{}
+	switch variables := divided.Variables(); len(variables) {
+	case 0:
+	case 1:
+		u, _ := newUnivariate(divided, variables[0])
+		var bases []univariateFactor
+		bases, f.Partial = u.factor()
+		for _, factor := range bases {
+			factors = append(factors, FactorPower{Base: factor.base.polynomial(variables[0]), Exponent: factor.exponent})
+		}
+	default:
+		factors, f.Partial = factorMultivariate(divided)
+	}
+	// the factors are normalized, so the rest is a constant
+	product := NewConstant(big.NewRat(1, 1))
+	for _, factor := range factors {
+		product = product.Mul(factor.Base.Pow(factor.Exponent))
+	}
+	rest, _ := divided.Quo(product)
+	c, _ := rest.Constant()
+	f.Content.Mul(f.Content, c)
+	f.Factors = append(f.Factors, factors...)
+	sort.SliceStable(f.Factors, func(i, j int) bool {
+		a, b := f.Factors[i].Base, f.Factors[j].Base
+		da, db := 0, 0
+		for _, m := range a.terms {
+			da = max(da, m.Degree())
+		}
+		for _, m := range b.terms {
+			db = max(db, m.Degree())
+		}
+		return da < db
+	})
+	return f
+}
+
+// Expand expands an expression into a polynomial in standard form
+func Expand(n *Node) (*Node, error) {
+	p, err := NewPolynomial(n)
+	if err != nil {
+		return nil, err
+	}
+	return p.Node(), nil
+}
+
+// Collect expands an expression and collects its terms by decreasing powers
+// of a variable
+func Collect(n *Node, x string) (*Node, error) {
+	p, err := NewPolynomial(n)
+	if err != nil {
+		return nil, err
+	}
+	coefficients := p.Coefficients(x)
+	terms, negative := []*Node{}, []bool{}
+	for k := len(coefficients) - 1; k >= 0; k-- {
+		c := coefficients[k]
+		if c.IsZero() {
+			continue
+		}
+		sign := c.Terms()[0].Coefficient.Sign() < 0
+		if sign {
+			c = c.Scale(big.NewRat(-1, 1))
+		}
+		switch {
+		case k == 0:
+			terms = append(terms, c.Node())
+		case c.Equal(NewConstant(big.NewRat(1, 1))):
+			terms = append(terms, power(x, k))
+		default:
+			terms = append(terms, &Node{Operation: OperationMultiply, Left: c.Node(), Right: power(x, k)})
+		}
+		negative = append(negative, sign)
+	}
+	return sumTerms(terms, negative), nil
+}
+
+// Factor factors an expression that is a polynomial
+func Factor(n *Node) (*Node, error) {
+	p, err := NewPolynomial(n)
+	if err != nil {
+		return nil, err
+	}
+	return p.Factor().Node(), nil
+}
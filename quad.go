@@ -0,0 +1,278 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// DefaultQuadTolerance is the absolute error used when the tolerance
+	// isn't positive
+	DefaultQuadTolerance = 1e-10
+	// MaxQuadIntervals is the maximum number of subintervals
+	MaxQuadIntervals = 1 << 12
+)
+
+var (
+	// ErrQuadTolerance is returned when the error estimate doesn't reach the
+	// tolerance within MaxQuadIntervals subintervals
+	ErrQuadTolerance = errors.New("quadrature tolerance not reached")
+	// ErrQuadNonFinite is returned when the integrand isn't finite inside
+	// the range
+	ErrQuadNonFinite = errors.New("integrand not finite")
+)
+
+// kronrod are the nodes of the 15 point Kronrod rule on [-1, 1], the odd
+// ones are the nodes of the 7 point Gauss rule
+var kronrod = [8]float64{
+	0.991455371120812639206854697526329,
+	0.949107912342758524526189684047851,
+	0.864864423359769072789712788640926,
+	0.741531185599394439863864773280788,
+	0.586087235467691130294144845693013,
+	0.405845151377397166906606412076961,
+	0.207784955007898467600689403773245,
+	0,
+}
+
+// kronrodWeights are the weights of the 15 point Kronrod rule
+var kronrodWeights = [8]float64{
+	0.022935322010529224963732008058970,
+	0.063092092629978553290700663189204,
+	0.104790010322250183839876322541518,
+	0.140653259715525918745189590510238,
+	0.169004726639267902826583426598550,
+	0.190350578064785409913256402421014,
+	0.204432940075298892414161999234649,
+	0.209482141084727828012999174891714,
+}
+
+// gaussWeights are the weights of the 7 point Gauss rule
+var gaussWeights = [4]float64{
+	0.129484966168869693270611432679082,
+	0.279705391489276667901467771423780,
+	0.381830050505118944950369775488975,
+	0.417959183673469387755102040816327,
+}
+
+// QuadResult is the result of a definite integral
+type QuadResult struct {
+	// Value is the integral
+	Value float64
+	// Error is the estimated absolute error
+	Error float64
+	// Evaluations is the number of evaluations of the integrand
+	Evaluations int
+	// Intervals is the number of subintervals
+	Intervals int
+	// x, a and b are the variable and the range
+	x    string
+	a, b float64
+}
+
+// interval is a subinterval with its integral and error estimate
+type interval struct {
+	a, b  float64
+	value float64
+	error float64
+}
+
+// quadrature integrates a function over subintervals of [a, b] with the
+// 7 point Gauss and 15 point Kronrod rules
+type quadrature struct {
+	ctx         context.Context
+	f           func(t float64) float64
+	evaluations int
+}
+
+// kronrod integrates over an interval, the difference from the Gauss rule
+// is the error estimate
+func (q *quadrature) kronrod(a, b float64) (interval, error) {
+	center, half := (a+b)/2, (b-a)/2
+	k, g := 0.0, 0.0
+	for i, node := range kronrod {
+		points := []float64{center - half*node, center + half*node}
+		if node == 0 {
+			points = points[:1]
+		}
+		for _, t := range points {
+			v := q.f(t)
+			q.evaluations++
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return interval{}, fmt.Errorf("%w: at %g", ErrQuadNonFinite, t)
+			}
+			k += kronrodWeights[i] * v
+			if i%2 == 1 {
+				g += gaussWeights[i/2] * v
+			}
+		}
+	}
+	return interval{
+		a:     a,
+		b:     b,
+		value: k * half,
+		error: math.Abs((k - g) * half),
+	}, nil
+}
+
+// integrate bisects the interval with the largest error until the total
+// error is within the tolerance
+func (q *quadrature) integrate(a, b, tolerance float64) (float64, float64, int, error) {
+	first, err := q.kronrod(a, b)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	intervals := []interval{first}
+	for {
+		value, total, worst := 0.0, 0.0, 0
+		for i, v := range intervals {
+			value += v.value
+			total += v.error
+			if v.error > intervals[worst].error {
+				worst = i
+			}
+		}
+		if total <= math.Max(tolerance, tolerance*math.Abs(value)) {
+			return value, total, len(intervals), nil
+		}
+		if err := q.ctx.Err(); err != nil {
+			return value, total, len(intervals), fmt.Errorf("quad: %w", err)
+		}
+		w := intervals[worst]
+		middle := (w.a + w.b) / 2
+		if len(intervals) >= MaxQuadIntervals || middle <= w.a || middle >= w.b {
+			return value, total, len(intervals), fmt.Errorf("%w: error %g", ErrQuadTolerance, total)
+		}
+		left, err := q.kronrod(w.a, middle)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		right, err := q.kronrod(middle, w.b)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		intervals[worst] = left
+		intervals = append(intervals, right)
+	}
+}
+
+// Quad integrates an expression of one variable x from a to b with adaptive
+// Gauss-Kronrod quadrature to within the absolute or relative tolerance.
+// The expression is evaluated with a Plan. Infinite ranges are mapped to
+// finite ones and singular endpoints are smoothed with variable transforms.
+func Quad(n *Node, x string, a, b, tolerance float64) (*QuadResult, error) {
+	return QuadContext(context.Background(), n, x, a, b, tolerance)
+}
+
+// QuadContext is Quad stopped when the context is done, which is checked
+// between the bisections of the subintervals
+func QuadContext(ctx context.Context, n *Node, x string, a, b, tolerance float64) (*QuadResult, error) {
+	if n == nil {
+		return nil, errors.New("quad: nil expression")
+	}
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return nil, errors.New("quad: range is NaN")
+	}
+	for _, name := range n.FreeVariables() {
+		if name != x {
+			return nil, fmt.Errorf("quad: free variable %s", name)
+		}
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultQuadTolerance
+	}
+	r := &QuadResult{x: x, a: a, b: b}
+	if a == b {
+		return r, nil
+	}
+	sign := 1.0
+	if a > b {
+		a, b, sign = b, a, -1
+	}
+
+	plan := NewPlan(n)
+	f := func(v float64) float64 {
+		return plan.Evaluate(map[string]float64{x: v})[0]
+	}
+	singular := func(v float64) bool {
+		y := f(v)
+		return math.IsNaN(y) || math.IsInf(y, 0)
+	}
+	q := &quadrature{ctx: ctx}
+	lower, upper := 0.0, 1.0
+	switch {
+	case math.IsInf(a, -1) && math.IsInf(b, 1):
+		// x = t/(1-t^2)
+		lower, upper = -1, 1
+		q.f = func(t float64) float64 {
+			d := 1 - t*t
+			return f(t/d) * (1 + t*t) / (d * d)
+		}
+	case math.IsInf(b, 1):
+		// x = a + t/(1-t)
+		q.f = func(t float64) float64 {
+			d := 1 - t
+			return f(a+t/d) / (d * d)
+		}
+	case math.IsInf(a, -1):
+		// x = b - (1-t)/t
+		q.f = func(t float64) float64 {
+			return f(b-(1-t)/t) / (t * t)
+		}
+	case singular(a) || singular(b):
+		// x = a + (b-a)(3t^2 - 2t^3) vanishes to second order at the ends
+		q.f = func(t float64) float64 {
+			return f(a+(b-a)*t*t*(3-2*t)) * 6 * (b - a) * t * (1 - t)
+		}
+	default:
+		lower, upper = a, b
+		q.f = f
+	}
+	value, e, intervals, err := q.integrate(lower, upper, tolerance)
+	r.Value, r.Error, r.Evaluations, r.Intervals = sign*value, e, q.evaluations, intervals
+	return r, err
+}
+
+// Check compares the integral with F(b) - F(a) for an antiderivative F,
+// using limits at infinite or singular endpoints, and returns the difference
+// and whether it is within the error estimate
+func (r *QuadResult) Check(antiderivative *Node) (float64, bool, error) {
+	at := func(point float64, direction Direction) (float64, error) {
+		v := antiderivative.Calculate(map[string]float64{r.x: point})
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return v, nil
+		}
+		l, err := Limit(antiderivative, r.x, point, direction)
+		if err != nil {
+			return 0, err
+		}
+		if l.Status != LimitExists {
+			return 0, fmt.Errorf("quad: antiderivative %s at %g", l.Status, point)
+		}
+		return l.Value.Calculate(nil), nil
+	}
+	if r.a == r.b {
+		return r.Value, r.Value == 0, nil
+	}
+	lower, upper := DirectionRight, DirectionLeft
+	if r.a > r.b {
+		lower, upper = upper, lower
+	}
+	fa, err := at(r.a, lower)
+	if err != nil {
+		return 0, false, err
+	}
+	fb, err := at(r.b, upper)
+	if err != nil {
+		return 0, false, err
+	}
+	difference := fb - fa - r.Value
+	tolerance := math.Max(10*r.Error, 1e-9*math.Max(1, math.Abs(r.Value)))
+	return difference, math.Abs(difference) <= tolerance, nil
+}
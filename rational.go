@@ -0,0 +1,451 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// ErrNotRational is returned when an expression isn't a rational function
+var ErrNotRational = errors.New("not a rational function")
+
+// leading returns the leading monomial of a non zero polynomial
+func (p *Polynomial) leading() Monomial {
+	return p.Terms()[0]
+}
+
+// normalized returns the polynomial scaled to have integer coefficients
+// without a common factor and a positive leading coefficient
+func (p *Polynomial) normalized() *Polynomial {
+	if p.IsZero() {
+		return p
+	}
+	return p.Scale(new(big.Rat).Inv(p.content()))
+}
+
+// Quo divides the polynomial by q if q divides it exactly
+func (p *Polynomial) Quo(q *Polynomial) (*Polynomial, bool) {
+	if q.IsZero() {
+		return nil, false
+	}
+	quotient := &Polynomial{terms: make(map[string]Monomial)}
+	remainder := p
+	divisor := q.leading()
+	for !remainder.IsZero() {
+		m := remainder.leading()
+		powers := make(map[string]int)
+		for name, power := range m.Powers {
+			if power -= divisor.Powers[name]; power > 0 {
+				powers[name] = power
+			} else if power < 0 {
+				return nil, false
+			}
+		}
+		for name := range divisor.Powers {
+			if _, ok := m.Powers[name]; !ok {
+				return nil, false
+			}
+		}
+		t := &Polynomial{terms: make(map[string]Monomial)}
+		t.add(Monomial{Coefficient: new(big.Rat).Quo(m.Coefficient, divisor.Coefficient), Powers: powers})
+		quotient = quotient.Add(t)
+		remainder = remainder.Sub(t.Mul(q))
+	}
+	return quotient, true
+}
+
+// fromCoefficients builds a polynomial in x from polynomial coefficients
+// indexed by power
+func fromCoefficients(coefficients []*Polynomial, x string) *Polynomial {
+	a := &Polynomial{terms: make(map[string]Monomial)}
+	for k, c := range coefficients {
+		if k == 0 {
+			a = a.Add(c)
+			continue
+		}
+		a = a.Add(c.Mul(NewVariable(x).Pow(k)))
+	}
+	return a
+}
+
+// pseudoRemainder returns the pseudo remainder of a divided by b as
+// polynomials in x
+func pseudoRemainder(a, b *Polynomial, x string) *Polynomial {
+	n := b.Degree(x)
+	lead := b.Coefficients(x)[n]
+	for !a.IsZero() && a.Degree(x) >= n {
+		m := a.Degree(x)
+		c := a.Coefficients(x)[m]
+		a = a.Mul(lead).Sub(c.Mul(NewVariable(x).Pow(m - n)).Mul(b))
+	}
+	return a
+}
+
+// contentIn returns the gcd of the coefficients of a polynomial in x
+func contentIn(p *Polynomial, x string) *Polynomial {
+	content := &Polynomial{terms: make(map[string]Monomial)}
+	for _, c := range p.Coefficients(x) {
+		content = GCD(content, c)
+	}
+	return content
+}
+
+// GCD returns the greatest common divisor of two polynomials normalized to
+// have integer coefficients without a common factor and a positive leading
+// coefficient, by the primitive polynomial remainder sequence in the first
+// variable with the contents computed recursively
+func GCD(p, q *Polynomial) *Polynomial {
+	switch {
+	case p.IsZero():
+		return q.normalized()
+	case q.IsZero():
+		return p.normalized()
+	}
+	variables := append(p.Variables(), q.Variables()...)
+	if len(variables) == 0 {
+		return NewConstant(big.NewRat(1, 1))
+	}
+	x := slices.Min(variables)
+	cp, cq := contentIn(p, x), contentIn(q, x)
+	a, _ := p.Quo(cp)
+	b, _ := q.Quo(cq)
+	if a.Degree(x) < b.Degree(x) {
+		a, b = b, a
+	}
+	for b.Degree(x) > 0 {
+		r := pseudoRemainder(a, b, x)
+		if r.IsZero() {
+			break
+		}
+		c := contentIn(r, x)
+		a = b
+		b, _ = r.Quo(c)
+	}
+	if b.Degree(x) == 0 {
+		b = NewConstant(big.NewRat(1, 1))
+	} else {
+		b, _ = b.Quo(contentIn(b, x))
+	}
+	return GCD(cp, cq).Mul(b).normalized()
+}
+
+// Rational is a rational function, a quotient of polynomials
+type Rational struct {
+	Numerator   *Polynomial
+	Denominator *Polynomial
+}
+
+// NewRational creates a rational function in lowest terms, with a
+// denominator that has integer coefficients without a common factor and a
+// positive leading coefficient
+func NewRational(numerator, denominator *Polynomial) (*Rational, error) {
+	if denominator.IsZero() {
+		return nil, fmt.Errorf("%w: division by zero", ErrNotRational)
+	}
+	g := GCD(numerator, denominator)
+	n, _ := numerator.Quo(g)
+	d, _ := denominator.Quo(g)
+	c := new(big.Rat).Inv(d.content())
+	return &Rational{
+		Numerator:   n.Scale(c),
+		Denominator: d.Scale(c),
+	}, nil
+}
+
+// Add returns the sum of two rational functions
+func (r *Rational) Add(s *Rational) *Rational {
+	a, _ := NewRational(r.Numerator.Mul(s.Denominator).Add(s.Numerator.Mul(r.Denominator)), r.Denominator.Mul(s.Denominator))
+	return a
+}
+
+// Sub returns the difference of two rational functions
+func (r *Rational) Sub(s *Rational) *Rational {
+	a, _ := NewRational(r.Numerator.Mul(s.Denominator).Sub(s.Numerator.Mul(r.Denominator)), r.Denominator.Mul(s.Denominator))
+	return a
+}
+
+// Mul returns the product of two rational functions
+func (r *Rational) Mul(s *Rational) *Rational {
+	a, _ := NewRational(r.Numerator.Mul(s.Numerator), r.Denominator.Mul(s.Denominator))
+	return a
+}
+
+// Quo returns the quotient of two rational functions
+func (r *Rational) Quo(s *Rational) (*Rational, error) {
+	return NewRational(r.Numerator.Mul(s.Denominator), r.Denominator.Mul(s.Numerator))
+}
+
+// Pow returns the rational function raised to an integer power
+func (r *Rational) Pow(k int) (*Rational, error) {
+	if k < 0 {
+		return NewRational(r.Denominator.Pow(-k), r.Numerator.Pow(-k))
+	}
+	return &Rational{Numerator: r.Numerator.Pow(k), Denominator: r.Denominator.Pow(k)}, nil
+}
+
+// ParseRational converts a tree to a rational function, the tree may only
+// contain numbers, variables, +, -, *, /, negation and powers with constant
+// integer exponents
+func ParseRational(n *Node) (*Rational, error) {
+	one := NewConstant(big.NewRat(1, 1))
+	var process func(n *Node) (*Rational, error)
+	process = func(n *Node) (*Rational, error) {
+		if n == nil {
+			return nil, fmt.Errorf("%w: missing operand", ErrNotRational)
+		}
+		switch n.Operation {
+		case OperationNumber, OperationVariable:
+			p, err := NewPolynomial(n)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrNotRational, err)
+			}
+			return &Rational{Numerator: p, Denominator: one}, nil
+		case OperationNegate:
+			a, err := process(n.Left)
+			if err != nil {
+				return nil, err
+			}
+			return &Rational{Numerator: a.Numerator.Scale(big.NewRat(-1, 1)), Denominator: a.Denominator}, nil
+		case OperationAdd, OperationSubtract, OperationMultiply, OperationDivide, OperationExponentiation:
+			a, err := process(n.Left)
+			if err != nil {
+				return nil, err
+			}
+			b, err := process(n.Right)
+			if err != nil {
+				return nil, err
+			}
+			switch n.Operation {
+			case OperationAdd:
+				return a.Add(b), nil
+			case OperationSubtract:
+				return a.Sub(b), nil
+			case OperationMultiply:
+				return a.Mul(b), nil
+			case OperationDivide:
+				return a.Quo(b)
+			}
+			c, ok := b.Numerator.Constant()
+			if !ok || !b.Denominator.Equal(one) || !c.IsInt() ||
+				new(big.Int).Abs(c.Num()).Cmp(big.NewInt(MaxPolynomialExponent)) > 0 {
+				return nil, fmt.Errorf("%w: exponent %s", ErrNotRational, n.Right)
+			}
+			return a.Pow(int(c.Num().Int64()))
+		}
+		return nil, fmt.Errorf("%w: operation %s", ErrNotRational, n.Operation)
+	}
+	return process(n)
+}
+
+// Node converts the rational function to a tree
+func (r *Rational) Node() *Node {
+	if c, ok := r.Denominator.Constant(); ok {
+		return r.Numerator.Scale(new(big.Rat).Inv(c)).Node()
+	}
+	return &Node{
+		Operation: OperationDivide,
+		Left:      r.Numerator.Node(),
+		Right:     r.Denominator.Node(),
+	}
+}
+
+// Together puts a rational expression over a common denominator in lowest
+// terms
+func Together(n *Node) (*Node, error) {
+	r, err := ParseRational(n)
+	if err != nil {
+		return nil, err
+	}
+	return r.Node(), nil
+}
+
+// univariate is a univariate polynomial given by its coefficients indexed
+// by power, without trailing zeros
+type univariate []*big.Rat
+
+// newUnivariate converts a polynomial in x to a univariate polynomial
+func newUnivariate(p *Polynomial, x string) (univariate, bool) {
+	u := univariate{}
+	for _, c := range p.Coefficients(x) {
+		v, ok := c.Constant()
+		if !ok {
+			return nil, false
+		}
+		u = append(u, v)
+	}
+	return u, true
+}
+
+// polynomial converts a univariate polynomial to a polynomial in x
+func (u univariate) polynomial(x string) *Polynomial {
+	coefficients := make([]*Polynomial, len(u))
+	for i, c := range u {
+		coefficients[i] = NewConstant(c)
+	}
+	return fromCoefficients(coefficients, x)
+}
+
+// trim removes trailing zeros
+func (u univariate) trim() univariate {
+	for len(u) > 0 && u[len(u)-1].Sign() == 0 {
+		u = u[:len(u)-1]
+	}
+	return u
+}
+
+// sub returns the difference of univariate polynomials
+func (u univariate) sub(v univariate) univariate {
+	a := make(univariate, max(len(u), len(v)))
+	for i := range a {
+		a[i] = new(big.Rat)
+		if i < len(u) {
+			a[i].Add(a[i], u[i])
+		}
+		if i < len(v) {
+			a[i].Sub(a[i], v[i])
+		}
+	}
+	return a.trim()
+}
+
+// mul returns the product of univariate polynomials
+func (u univariate) mul(v univariate) univariate {
+	if len(u) == 0 || len(v) == 0 {
+		return univariate{}
+	}
+	a := make(univariate, len(u)+len(v)-1)
+	for i := range a {
+		a[i] = new(big.Rat)
+	}
+	for i, x := range u {
+		for j, y := range v {
+			a[i+j].Add(a[i+j], new(big.Rat).Mul(x, y))
+		}
+	}
+	return a.trim()
+}
+
+// divide returns the quotient and remainder of univariate polynomials
+func (u univariate) divide(v univariate) (univariate, univariate) {
+	remainder := append(univariate{}, u...)
+	if len(remainder) < len(v) {
+		return univariate{}, remainder
+	}
+	quotient := make(univariate, len(remainder)-len(v)+1)
+	for i := range quotient {
+		quotient[i] = new(big.Rat)
+	}
+	lead := v[len(v)-1]
+	for len(remainder) >= len(v) {
+		k := len(remainder) - len(v)
+		c := new(big.Rat).Quo(remainder[len(remainder)-1], lead)
+		quotient[k] = c
+		t := make(univariate, k+1)
+		for i := range t {
+			t[i] = new(big.Rat)
+		}
+		t[k] = c
+		remainder = remainder.sub(t.mul(v))
+	}
+	return quotient.trim(), remainder
+}
+
+// inverse returns s such that s*u = 1 modulo v for coprime u and v, by the
+// extended euclidean algorithm
+func (u univariate) inverse(v univariate) univariate {
+	r0, r1 := v, u
+	s0, s1 := univariate{}, univariate{big.NewRat(1, 1)}
+	for len(r1) > 1 {
+		q, r := r0.divide(r1)
+		r0, r1 = r1, r
+		s0, s1 = s1, s0.sub(q.mul(s1))
+	}
+	c := new(big.Rat).Inv(r1[0])
+	a := make(univariate, len(s1))
+	for i := range s1 {
+		a[i] = new(big.Rat).Mul(s1[i], c)
+	}
+	_, a = a.divide(v)
+	return a
+}
+
+// Apart decomposes a univariate rational expression in x into a polynomial
+// and partial fractions over the factors of the denominator found by Factor
+func Apart(n *Node, x string) (*Node, error) {
+	r, err := ParseRational(n)
+	if err != nil {
+		return nil, err
+	}
+	numerator, ok := newUnivariate(r.Numerator, x)
+	denominator, dok := newUnivariate(r.Denominator, x)
+	if !ok || !dok {
+		return nil, fmt.Errorf("%w: not univariate in %s", ErrNotRational, x)
+	}
+	quotient, remainder := numerator.trim().divide(denominator.trim())
+	terms := []*Node{}
+	if len(quotient) > 0 {
+		terms = append(terms, quotient.polynomial(x).Node())
+	}
+
+	f := r.Denominator.Factor()
+	scale := new(big.Rat).Inv(f.Content)
+	for i, factor := range f.Factors {
+		base, _ := newUnivariate(factor.Base, x)
+		power := univariate{big.NewRat(1, 1)}
+		for range factor.Exponent {
+			power = power.mul(base)
+		}
+		rest := univariate{big.NewRat(1, 1)}
+		for j, other := range f.Factors {
+			if j == i {
+				continue
+			}
+			b, _ := newUnivariate(other.Base, x)
+			for range other.Exponent {
+				rest = rest.mul(b)
+			}
+		}
+		// remainder/(power*rest) = a/power + b/rest where a = remainder/rest mod power
+		_, a := remainder.mul(rest.inverse(power)).divide(power)
+		for k := range a {
+			a[k] = new(big.Rat).Mul(a[k], scale)
+		}
+		// expand a in powers of the base to split it over its powers
+		fractions := []*Node{}
+		for j := factor.Exponent; j >= 1 && len(a) > 0; j-- {
+			q, digit := a.divide(base)
+			a = q
+			if len(digit) == 0 {
+				continue
+			}
+			denominator := factor.Base.Node()
+			if j > 1 {
+				denominator = &Node{
+					Operation: OperationExponentiation,
+					Left:      denominator,
+					Right:     &Node{Operation: OperationNumber, Value: float64(j)},
+				}
+			}
+			fractions = append(fractions, &Node{
+				Operation: OperationDivide,
+				Left:      digit.polynomial(x).Node(),
+				Right:     denominator,
+			})
+		}
+		slices.Reverse(fractions)
+		terms = append(terms, fractions...)
+	}
+	if len(terms) == 0 {
+		return &Node{Operation: OperationNumber, Value: 0}, nil
+	}
+	a := terms[0]
+	for _, term := range terms[1:] {
+		a = &Node{Operation: OperationAdd, Left: a, Right: term}
+	}
+	return a, nil
+}
@@ -0,0 +1,296 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DefaultRewriteLimit is the default maximum number of rule applications
+const DefaultRewriteLimit = 1 << 16
+
+// ErrRewriteLimit is returned when rewriting doesn't reach a fixed point
+// within the limit
+var ErrRewriteLimit = errors.New("rewrite limit exceeded")
+
+// Bindings maps the names of pattern variables to the subtrees they matched
+type Bindings map[string]*Node
+
+// Rule is a rewrite rule replacing trees matching LHS with RHS
+type Rule struct {
+	Name string
+	LHS  *Node
+	RHS  *Node
+	// Condition if not nil must be true for the rule to apply
+	Condition func(b Bindings) bool
+	// Transform if not nil computes the replacement instead of RHS, the rule
+	// doesn't apply if it returns nil
+	Transform func(b Bindings) *Node
+}
+
+// patterns returns the names of the pattern variables of a tree
+func patterns(n *Node) map[string]bool {
+	names := make(map[string]bool)
+	var process func(n *Node)
+	process = func(n *Node) {
+		if n == nil {
+			return
+		}
+		if n.Operation == OperationPattern {
+			names[n.Variable] = true
+		}
+		for _, child := range n.children() {
+			process(child)
+		}
+	}
+	process(n)
+	return names
+}
+
+// ParseRule parses a rule of the form lhs -> rhs where ?name is a pattern
+// variable, for example sin(?a)^2 + cos(?a)^2 -> 1
+func ParseRule(rule string) (*Rule, error) {
+	calc := &calculator[uint32]{Buffer: rule}
+	err := calc.Init()
+	if err != nil {
+		return nil, err
+	}
+	if err := calc.Parse(int(rulerewrite)); err != nil {
+		return nil, err
+	}
+	lhs, rhs := calc.Rulerewrite(calc.AST())
+	bound := patterns(lhs)
+	for name := range patterns(rhs) {
+		if !bound[name] {
+			return nil, fmt.Errorf("pattern variable ?%s is not bound by %s", name, lhs)
+		}
+	}
+	return &Rule{
+		Name: rule,
+		LHS:  lhs,
+		RHS:  rhs,
+	}, nil
+}
+
+// Match matches a pattern against a tree, a pattern variable matches any
+// subtree and all occurrences of it must match equal subtrees
+func Match(pattern, n *Node) (Bindings, bool) {
+	b := make(Bindings)
+	var process func(pattern, n *Node) bool
+	process = func(pattern, n *Node) bool {
+		if pattern == nil || n == nil {
+			return pattern == n
+		}
+		if pattern.Operation == OperationPattern {
+			if bound, ok := b[pattern.Variable]; ok {
+				return bound.Equal(n)
+			}
+			b[pattern.Variable] = n
+			return true
+		}
+		if pattern.Operation != n.Operation {
+			return false
+		}
+		switch {
+		case pattern.hasValue():
+			if pattern.Value != n.Value {
+				return false
+			}
+		case pattern.hasVariable():
+			if pattern.Variable != n.Variable {
+				return false
+			}
+		}
+		x, y := pattern.children(), n.children()
+		for i := range x {
+			if !process(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if !process(pattern, n) {
+		return nil, false
+	}
+	return b, true
+}
+
+// instantiate replaces the pattern variables of a tree with their bindings
+func instantiate(n *Node, b Bindings) *Node {
+	if n == nil {
+		return nil
+	}
+	if n.Operation == OperationPattern {
+		return b[n.Variable]
+	}
+	a := &Node{
+		Operation: n.Operation,
+		Value:     n.Value,
+		Variable:  n.Variable,
+	}
+	switch children := n.children(); len(children) {
+	case 1:
+		a.Left = instantiate(children[0], b)
+	case 2:
+		a.Left, a.Right = instantiate(children[0], b), instantiate(children[1], b)
+	}
+	return a
+}
+
+// Apply applies the rule to the root of a tree
+func (r *Rule) Apply(n *Node) (*Node, bool) {
+	b, ok := Match(r.LHS, n)
+	if !ok {
+		return nil, false
+	}
+	if r.Condition != nil && !r.Condition(b) {
+		return nil, false
+	}
+	if r.Transform != nil {
+		a := r.Transform(b)
+		return a, a != nil
+	}
+	return instantiate(r.RHS, b), true
+}
+
+// Rewriter rewrites trees with rules
+type Rewriter struct {
+	Rules []*Rule
+	// Limit is the maximum number of rule applications
+	Limit int
+}
+
+// NewRewriter creates a new rewriter
+func NewRewriter(rules ...*Rule) *Rewriter {
+	return &Rewriter{
+		Rules: rules,
+		Limit: DefaultRewriteLimit,
+	}
+}
+
+// Rewrite rewrites a tree bottom up to a fixed point: the children of a node
+// are rewritten first, then the first rule that applies to the node replaces
+// it and the replacement is rewritten
+func (r *Rewriter) Rewrite(n *Node) (*Node, error) {
+	steps := 0
+	var process func(n *Node) (*Node, error)
+	process = func(n *Node) (*Node, error) {
+		if n == nil {
+			return nil, nil
+		}
+		a := &Node{
+			Operation: n.Operation,
+			Value:     n.Value,
+			Variable:  n.Variable,
+		}
+		var err error
+		switch children := n.children(); len(children) {
+		case 1:
+			if a.Left, err = process(children[0]); err != nil {
+				return nil, err
+			}
+		case 2:
+			if a.Left, err = process(children[0]); err != nil {
+				return nil, err
+			}
+			if a.Right, err = process(children[1]); err != nil {
+				return nil, err
+			}
+		}
+		for _, rule := range r.Rules {
+			b, ok := rule.Apply(a)
+			if !ok {
+				continue
+			}
+			steps++
+			if steps > r.Limit {
+				return nil, ErrRewriteLimit
+			}
+			return process(b)
+		}
+		return a, nil
+	}
+	return process(n)
+}
+
+// mustParseRule parses a rule with a condition and panics on error
+func mustParseRule(rule string, condition func(b Bindings) bool) *Rule {
+	a, err := ParseRule(rule)
+	if err != nil {
+		panic(err)
+	}
+	a.Condition = condition
+	return a
+}
+
+// numericEquals returns a condition that is true if the pattern variables
+// are bound to numeric values equal to x, like Simplify
+func numericEquals(x int64, names ...string) func(b Bindings) bool {
+	return func(b Bindings) bool {
+		for _, name := range names {
+			n := b[name]
+			if !isNumeric(n.Operation) || !n.Equals(x) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// unaryRule creates a rule for an operation without syntax applied to a
+// numeric value equal to x
+func unaryRule(name string, o Operation, x int64, rhs *Node) *Rule {
+	return &Rule{
+		Name:      name,
+		LHS:       &Node{Operation: o, Left: &Node{Operation: OperationPattern, Variable: "z"}},
+		RHS:       rhs,
+		Condition: numericEquals(x, "z"),
+	}
+}
+
+// DefaultRules returns rules that simplify like Simplify, except that e^1
+// is e and log(e) is 1. Simplify makes a single pass, so rewriting to a fixed
+// point can simplify further, for example -(0) to 0.
+func DefaultRules() []*Rule {
+	zero, one := numericEquals(0, "z"), numericEquals(1, "o")
+	number := func(v float64) *Node {
+		return &Node{Operation: OperationNumber, Value: v}
+	}
+	return []*Rule{
+		mustParseRule("?z + ?a -> ?a", zero),
+		mustParseRule("?a + ?z -> ?a", zero),
+		mustParseRule("?z - ?a -> -?a", zero),
+		mustParseRule("?a - ?z -> ?a", zero),
+		mustParseRule("?z * ?a -> 0", zero),
+		mustParseRule("?a * ?z -> 0", zero),
+		mustParseRule("?o * ?a -> ?a", one),
+		mustParseRule("?a * ?o -> ?a", one),
+		mustParseRule("?z / ?a -> 0", zero),
+		{
+			Name:      "?a / ?z -> inf",
+			LHS:       mustParseRule("?a / ?z -> ?a", nil).LHS,
+			RHS:       number(math.Inf(1)),
+			Condition: zero,
+		},
+		mustParseRule("?a / ?o -> ?a", one),
+		mustParseRule("?a % ?o -> ?a", one),
+		mustParseRule("?z ^ ?a -> 0", zero),
+		mustParseRule("?a ^ ?z -> 1", zero),
+		mustParseRule("?o ^ ?a -> 1", one),
+		mustParseRule("?a ^ ?o -> ?a", one),
+		mustParseRule("-?z -> 0", zero),
+		unaryRule("exp(?z) -> 1", OperationNaturalExponentiation, 0, number(1)),
+		unaryRule("exp(?z) -> e", OperationNaturalExponentiation, 1, &Node{Operation: OperationNatural}),
+		{
+			Name: "log(e) -> 1",
+			LHS:  &Node{Operation: OperationNaturalLogarithm, Left: &Node{Operation: OperationNatural}},
+			RHS:  number(1),
+		},
+		unaryRule("sqrt(?z) -> 0", OperationSquareRoot, 0, number(0)),
+		unaryRule("sqrt(?z) -> 1", OperationSquareRoot, 1, number(1)),
+	}
+}
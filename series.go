@@ -0,0 +1,177 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// MaxRationalDenominator is the largest denominator of the rationals
+// recognized in floating point values
+const MaxRationalDenominator = 1 << 16
+
+// ErrSingularPoint is returned when an expression or one of its derivatives
+// isn't finite at a point
+var ErrSingularPoint = errors.New("singular point")
+
+// rationalize returns the rational with the smallest denominator up to
+// MaxRationalDenominator that is equal to v within rounding error, by
+// continued fractions
+func rationalize(v float64) (*big.Rat, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > 1<<53 {
+		return nil, false
+	}
+	tolerance := 1e-13 * math.Max(1, math.Abs(v))
+	// convergents h/k of the continued fraction of v
+	h0, h1, k0, k1 := big.NewInt(0), big.NewInt(1), big.NewInt(1), big.NewInt(0)
+	x := v
+	for range 64 {
+		a := math.Floor(x)
+		ai := big.NewInt(int64(a))
+		h0, h1 = h1, new(big.Int).Add(new(big.Int).Mul(ai, h1), h0)
+		k0, k1 = k1, new(big.Int).Add(new(big.Int).Mul(ai, k1), k0)
+		if k1.Cmp(big.NewInt(MaxRationalDenominator)) > 0 {
+			return nil, false
+		}
+		r := new(big.Rat).SetFrac(h1, k1)
+		if f, _ := r.Float64(); math.Abs(f-v) <= tolerance {
+			return r, true
+		}
+		if x-a == 0 {
+			break
+		}
+		x = 1 / (x - a)
+	}
+	return nil, false
+}
+
+// coefficientNode converts a coefficient to a tree, exactly if it is
+// rational
+func coefficientNode(v float64, exact *big.Rat) *Node {
+	if exact != nil {
+		return rationalNode(exact)
+	}
+	return &Node{Operation: OperationNumber, Value: v}
+}
+
+// SeriesResult is a truncated Taylor series
+type SeriesResult struct {
+	// Series is the sum of the terms c_k (x - point)^k
+	Series *Node
+	// Coefficients are the coefficients c_k
+	Coefficients []float64
+	// Exact are the coefficients that are rational, or nil
+	Exact []*big.Rat
+	// next are the coefficients of the first two omitted terms
+	next [2]float64
+	// point is the expansion point
+	point float64
+}
+
+// Remainder estimates the error of the series at x with the first two
+// omitted terms
+func (s *SeriesResult) Remainder(x float64) float64 {
+	h, order := math.Abs(x-s.point), float64(len(s.Coefficients))
+	return math.Abs(s.next[0])*math.Pow(h, order) + math.Abs(s.next[1])*math.Pow(h, order+1)
+}
+
+// Series expands an expression of one variable x in a Taylor series about
+// point up to the given order, a Maclaurin series if point is 0. The
+// derivatives are computed with a DAG and the coefficients are exact
+// rationals when they are recognized as such. Other variables must be
+// replaced with Substitute first.
+func Series(n *Node, x string, point float64, order int) (*SeriesResult, error) {
+	if n == nil {
+		return nil, errors.New("series: nil expression")
+	}
+	if order < 0 {
+		return nil, fmt.Errorf("series: negative order %d", order)
+	}
+	for _, name := range n.FreeVariables() {
+		if name != x {
+			return nil, fmt.Errorf("series: free variable %s", name)
+		}
+	}
+	d := NewDAG()
+	ids := []ID{d.Add(n)}
+	for len(ids) < order+3 {
+		ids = append(ids, d.Derivative(ids[len(ids)-1], x))
+	}
+	values := d.Evaluate(map[string]float64{x: point}, ids...)
+
+	s := &SeriesResult{
+		Coefficients: make([]float64, order+1),
+		Exact:        make([]*big.Rat, order+1),
+		point:        point,
+	}
+	factorial := big.NewInt(1)
+	for k, v := range values {
+		if k > 0 {
+			factorial.Mul(factorial, big.NewInt(int64(k)))
+		}
+		f, _ := new(big.Float).SetInt(factorial).Float64()
+		if k > order {
+			s.next[k-order-1] = v / f
+			continue
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%w: derivative %d of %s at %s = %g", ErrSingularPoint, k, n, x, point)
+		}
+		s.Coefficients[k] = v / f
+		if r, ok := rationalize(v); ok {
+			s.Exact[k] = r.Quo(r, new(big.Rat).SetInt(factorial))
+			if s.Exact[k].Sign() == 0 {
+				// rounding error of a zero derivative
+				s.Coefficients[k] = 0
+			}
+		}
+	}
+
+	shift := &Node{Operation: OperationVariable, Variable: x}
+	if point != 0 {
+		operation := OperationSubtract
+		if point < 0 {
+			operation = OperationAdd
+		}
+		shift = &Node{
+			Operation: operation,
+			Left:      shift,
+			Right:     limitNode(math.Abs(point)),
+		}
+	}
+	terms, negative := []*Node{}, []bool{}
+	for k, c := range s.Coefficients {
+		if c == 0 {
+			continue
+		}
+		var exact *big.Rat
+		if s.Exact[k] != nil {
+			exact = new(big.Rat).Abs(s.Exact[k])
+		}
+		factors := []*Node{}
+		// a unit coefficient is only omitted if it is exactly one
+		if k == 0 || exact == nil || exact.Cmp(big.NewRat(1, 1)) != 0 {
+			factors = append(factors, coefficientNode(math.Abs(c), exact))
+		}
+		if k > 0 {
+			var a *Node = shift
+			if k > 1 {
+				a = &Node{
+					Operation: OperationExponentiation,
+					Left:      shift,
+					Right:     &Node{Operation: OperationNumber, Value: float64(k)},
+				}
+			}
+			factors = append(factors, a)
+		}
+		terms = append(terms, product(factors))
+		negative = append(negative, c < 0)
+	}
+	s.Series = sumTerms(terms, negative)
+	return s, nil
+}
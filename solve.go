@@ -0,0 +1,504 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+)
+
+var (
+	// ErrNotSolvable is returned when an equation can't be solved for a
+	// variable
+	ErrNotSolvable = errors.New("equation not solvable")
+	// ErrInfiniteSolutions is returned when every value of the variable
+	// solves an equation
+	ErrInfiniteSolutions = errors.New("infinitely many solutions")
+)
+
+// Relation is the relation of an expression to zero in a condition
+type Relation int
+
+const (
+	// RelationZero is an expression equal to zero
+	RelationZero Relation = iota
+	// RelationNonZero is an expression not equal to zero
+	RelationNonZero
+	// RelationPositive is an expression greater than zero
+	RelationPositive
+	// RelationNonNegative is an expression greater than or equal to zero
+	RelationNonNegative
+	// RelationNegative is an expression less than zero
+	RelationNegative
+)
+
+// String returns the string form of the relation
+func (r Relation) String() string {
+	switch r {
+	case RelationZero:
+		return "= 0"
+	case RelationNonZero:
+		return "!= 0"
+	case RelationPositive:
+		return "> 0"
+	case RelationNonNegative:
+		return ">= 0"
+	}
+	return "< 0"
+}
+
+// holds returns if a value is in the relation to zero
+func (r Relation) holds(v float64) bool {
+	switch r {
+	case RelationZero:
+		return v == 0
+	case RelationNonZero:
+		return v != 0
+	case RelationPositive:
+		return v > 0
+	case RelationNonNegative:
+		return v >= 0
+	}
+	return v < 0
+}
+
+// Condition is a relation an expression of the other variables must be in
+// for a solution to hold
+type Condition struct {
+	Expression *Node
+	Relation   Relation
+}
+
+// String returns the string form of the condition
+func (c Condition) String() string {
+	return c.Expression.String() + " " + c.Relation.String()
+}
+
+// Solution is a value of the variable that solves an equation when all of
+// the conditions hold
+type Solution struct {
+	Value      *Node
+	Conditions []Condition
+}
+
+// String returns the string form of the solution
+func (s Solution) String() string {
+	a := s.Value.String()
+	for i, c := range s.Conditions {
+		if i == 0 {
+			a += " if "
+		} else {
+			a += " and "
+		}
+		a += c.String()
+	}
+	return a
+}
+
+// solver solves an equation for the variable x
+type solver struct {
+	x         string
+	solutions []Solution
+	// denominators are the denominators of the equation that depend on x,
+	// which must not be zero at a solution
+	denominators []*Node
+}
+
+// collect collects the divisors and the bases of negative powers of a
+// tree that depend on x, before simplifying cancels them
+func (s *solver) collect(n *Node) {
+	x := map[string]bool{s.x: true}
+	var process func(n *Node)
+	process = func(n *Node) {
+		if n == nil {
+			return
+		}
+		process(n.Left)
+		process(n.Right)
+		var d *Node
+		switch n.Operation {
+		case OperationDivide:
+			d = n.Right
+		case OperationExponentiation:
+			if len(n.Right.FreeVariables()) == 0 && n.Right.Calculate(nil) < 0 {
+				d = n.Left
+			}
+		}
+		if d == nil || !d.dependsOn(x) {
+			return
+		}
+		for _, e := range s.denominators {
+			if e.Equal(d) {
+				return
+			}
+		}
+		s.denominators = append(s.denominators, d)
+	}
+	process(n)
+}
+
+// exact returns an expression in lowest terms if it is rational, and a
+// constant as a rational number if it is one
+func exact(n *Node) *Node {
+	if r, err := ParseRational(n); err == nil {
+		return r.Node()
+	}
+	if len(n.FreeVariables()) == 0 {
+		if r, ok := rationalize(n.Calculate(nil)); ok {
+			return rationalNode(r)
+		}
+	}
+	return n
+}
+
+// condition adds a condition to a list, returning false if the condition is
+// known not to hold
+func condition(conditions []Condition, n *Node, relation Relation) ([]Condition, bool) {
+	n = exact(n)
+	if r, err := ParseRational(n); err == nil {
+		if _, ok := r.Denominator.Constant(); ok && !r.Numerator.IsZero() {
+			// scaling by a positive number doesn't change the relation
+			n = r.Numerator.Scale(new(big.Rat).Abs(new(big.Rat).Inv(r.Numerator.content()))).Node()
+		}
+	}
+	if n.Operation == OperationNegate && (relation == RelationZero || relation == RelationNonZero) {
+		// the sign doesn't change if it is zero
+		n = n.Left
+	}
+	if len(n.FreeVariables()) == 0 {
+		v := n.Calculate(nil)
+		return conditions, !math.IsNaN(v) && relation.holds(v)
+	}
+	c := Condition{Expression: n, Relation: relation}
+	for _, d := range conditions {
+		if d.Relation == relation && d.Expression.Equal(n) {
+			return conditions, true
+		}
+	}
+	return append(slices.Clip(conditions), c), true
+}
+
+// add adds a solution unless a condition is known not to hold, a
+// denominator of the equation is zero at it or it is already a solution
+func (s *solver) add(value *Node, conditions []Condition) {
+	value = exact(value)
+	for _, d := range s.denominators {
+		at, err := d.Substitute(map[string]*Node{s.x: value})
+		if err != nil {
+			return
+		}
+		var ok bool
+		if conditions, ok = condition(conditions, at, RelationNonZero); !ok {
+			return
+		}
+	}
+	if len(value.FreeVariables()) == 0 {
+		if v := value.Calculate(nil); math.IsNaN(v) || math.IsInf(v, 0) {
+			return
+		}
+	}
+	for _, solution := range s.solutions {
+		if solution.Value.Equal(value) && slices.EqualFunc(solution.Conditions, conditions, func(a, b Condition) bool {
+			return a.Relation == b.Relation && a.Expression.Equal(b.Expression)
+		}) {
+			return
+		}
+	}
+	s.solutions = append(s.solutions, Solution{Value: value, Conditions: conditions})
+}
+
+// polynomial solves p = 0 for a polynomial of degree one or two in x, or one
+// that factors into such polynomials, where the denominator d must not be zero
+func (s *solver) polynomial(p, d *Polynomial, conditions []Condition) error {
+	// the denominator must not vanish at a solution
+	add := func(value *Node, conditions []Condition) {
+		if d.Degree(s.x) > 0 {
+			at, _ := d.Node().Substitute(map[string]*Node{s.x: value})
+			var ok bool
+			if conditions, ok = condition(conditions, at, RelationNonZero); !ok {
+				return
+			}
+		}
+		s.add(value, conditions)
+	}
+	quotient := func(a, b *Polynomial) *Node {
+		r, _ := NewRational(a, b)
+		return r.Node()
+	}
+	coefficients := p.Coefficients(s.x)
+	switch len(coefficients) - 1 {
+	case -1:
+		every := "every " + s.x
+		for i, d := range s.denominators {
+			if i == 0 {
+				every += " if "
+			} else {
+				every += " and "
+			}
+			every += Condition{Expression: exact(d), Relation: RelationNonZero}.String()
+		}
+		return fmt.Errorf("%w: %s", ErrInfiniteSolutions, every)
+	case 0:
+		if _, ok := p.Constant(); ok {
+			return nil
+		}
+		return fmt.Errorf("%w: %s doesn't depend on %s", ErrNotSolvable, p, s.x)
+	case 1:
+		a, b := coefficients[1], coefficients[0]
+		conditions, ok := condition(conditions, a.Node(), RelationNonZero)
+		if ok {
+			add(quotient(b.Scale(big.NewRat(-1, 1)), a), conditions)
+		}
+		return nil
+	case 2:
+		a, b, c := coefficients[2], coefficients[1], coefficients[0]
+		if _, ok := a.Constant(); !ok {
+			// the equation is linear when a is zero
+			if linear, ok := condition(conditions, a.Node(), RelationZero); ok {
+				if linear, ok = condition(linear, b.Node(), RelationNonZero); ok {
+					add(quotient(c.Scale(big.NewRat(-1, 1)), b), linear)
+				}
+			}
+		}
+		conditions, ok := condition(conditions, a.Node(), RelationNonZero)
+		if !ok {
+			return nil
+		}
+		two := a.Scale(big.NewRat(2, 1))
+		center := quotient(b.Scale(big.NewRat(-1, 1)), two)
+		// x = -b/(2a) ± sqrt((b^2 - 4ac)/(4a^2))
+		discriminant := b.Mul(b).Sub(a.Mul(c).Scale(big.NewRat(4, 1)))
+		if discriminant.IsZero() {
+			add(center, conditions)
+			return nil
+		}
+		r, _ := NewRational(discriminant, two.Mul(two))
+		conditions, ok = condition(conditions, discriminant.Node(), RelationNonNegative)
+		if !ok {
+			return nil
+		}
+		root := &Node{Operation: OperationSquareRoot, Left: r.Node()}
+		if b.IsZero() {
+			add(&Node{Operation: OperationNegate, Left: root}, conditions)
+			add(root, conditions)
+			return nil
+		}
+		for _, operation := range []Operation{OperationSubtract, OperationAdd} {
+			add(&Node{Operation: operation, Left: center, Right: root}, conditions)
+		}
+		return nil
+	}
+	f := p.Factor()
+	for _, factor := range f.Factors {
+		if factor.Base.Degree(s.x) > 2 {
+			return fmt.Errorf("%w: factor %s has degree %d in %s", ErrNotSolvable, factor.Base, factor.Base.Degree(s.x), s.x)
+		}
+	}
+	for _, factor := range f.Factors {
+		if factor.Base.Degree(s.x) > 0 {
+			if err := s.polynomial(factor.Base, d, conditions); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isolate solves lhs = rhs by applying inverse operations to both sides
+// until x is alone on the left, rhs doesn't depend on x
+func (s *solver) isolate(lhs, rhs *Node, conditions []Condition) error {
+	x := map[string]bool{s.x: true}
+	// polynomials of higher degree may still be isolated, like x^3 = y
+	var failed error
+	if r, err := ParseRational(&Node{Operation: OperationSubtract, Left: lhs, Right: rhs}); err == nil {
+		failed = s.polynomial(r.Numerator, r.Denominator, conditions)
+		if !errors.Is(failed, ErrNotSolvable) {
+			return failed
+		}
+	}
+	binary := func(operation Operation, left, right *Node) *Node {
+		return &Node{Operation: operation, Left: left, Right: right}
+	}
+	unary := func(operation Operation, left *Node) *Node {
+		return &Node{Operation: operation, Left: left}
+	}
+	number := func(v float64) *Node {
+		return &Node{Operation: OperationNumber, Value: v}
+	}
+	switch lhs.Operation {
+	case OperationVariable:
+		s.add(rhs, conditions)
+		return nil
+	case OperationNegate:
+		return s.isolate(lhs.Left, unary(OperationNegate, rhs), conditions)
+	case OperationAdd, OperationSubtract, OperationMultiply, OperationDivide:
+		u, v := lhs.Left, lhs.Right
+		left := u.dependsOn(x)
+		if left && v.dependsOn(x) {
+			break
+		}
+		switch {
+		case lhs.Operation == OperationAdd && left:
+			return s.isolate(u, binary(OperationSubtract, rhs, v), conditions)
+		case lhs.Operation == OperationAdd:
+			return s.isolate(v, binary(OperationSubtract, rhs, u), conditions)
+		case lhs.Operation == OperationSubtract && left:
+			return s.isolate(u, binary(OperationAdd, rhs, v), conditions)
+		case lhs.Operation == OperationSubtract:
+			return s.isolate(v, binary(OperationSubtract, u, rhs), conditions)
+		case lhs.Operation == OperationMultiply:
+			if !left {
+				u, v = v, u
+			}
+			conditions, ok := condition(conditions, v, RelationNonZero)
+			if !ok {
+				return nil
+			}
+			return s.isolate(u, binary(OperationDivide, rhs, v), conditions)
+		case left:
+			conditions, ok := condition(conditions, v, RelationNonZero)
+			if !ok {
+				return nil
+			}
+			return s.isolate(u, binary(OperationMultiply, rhs, v), conditions)
+		default:
+			conditions, ok := condition(conditions, rhs, RelationNonZero)
+			if !ok {
+				return nil
+			}
+			return s.isolate(v, binary(OperationDivide, u, rhs), conditions)
+		}
+	case OperationExponentiation:
+		u, v := lhs.Left, lhs.Right
+		if u.dependsOn(x) && v.dependsOn(x) {
+			break
+		}
+		if !u.dependsOn(x) {
+			// u^v = rhs so v = log(rhs)/log(u)
+			conditions, ok := condition(conditions, u, RelationPositive)
+			if !ok {
+				return nil
+			}
+			if conditions, ok = condition(conditions, rhs, RelationPositive); !ok {
+				return nil
+			}
+			return s.isolate(v, binary(OperationDivide,
+				unary(OperationNaturalLogarithm, rhs), unary(OperationNaturalLogarithm, u)), conditions)
+		}
+		k := v.Calculate(nil)
+		if len(v.FreeVariables()) > 0 || k == 0 || math.IsNaN(k) || math.IsInf(k, 0) {
+			break
+		}
+		inverse := exact(binary(OperationDivide, number(1), v))
+		root := func(a *Node) *Node {
+			if k == 2 {
+				return unary(OperationSquareRoot, a)
+			}
+			return binary(OperationExponentiation, a, inverse)
+		}
+		sign := RelationNonNegative
+		if k < 0 {
+			sign = RelationPositive
+		}
+		if k != math.Trunc(k) {
+			conditions, ok := condition(conditions, rhs, sign)
+			if !ok {
+				return nil
+			}
+			return s.isolate(u, root(rhs), conditions)
+		}
+		if math.Mod(k, 2) == 0 {
+			// u = ±rhs^(1/k)
+			conditions, ok := condition(conditions, rhs, sign)
+			if !ok {
+				return nil
+			}
+			if err := s.isolate(u, unary(OperationNegate, root(rhs)), conditions); err != nil {
+				return err
+			}
+			return s.isolate(u, root(rhs), conditions)
+		}
+		// the real odd root of a negative number is -(-rhs)^(1/k)
+		if negative, ok := condition(conditions, rhs, RelationNegative); ok {
+			if err := s.isolate(u, unary(OperationNegate, root(unary(OperationNegate, rhs))), negative); err != nil {
+				return err
+			}
+		}
+		if conditions, ok := condition(conditions, rhs, sign); ok {
+			return s.isolate(u, root(rhs), conditions)
+		}
+		return nil
+	case OperationNaturalExponentiation:
+		conditions, ok := condition(conditions, rhs, RelationPositive)
+		if !ok {
+			return nil
+		}
+		return s.isolate(lhs.Left, unary(OperationNaturalLogarithm, rhs), conditions)
+	case OperationNaturalLogarithm:
+		return s.isolate(lhs.Left, unary(OperationNaturalExponentiation, rhs), conditions)
+	case OperationSquareRoot:
+		conditions, ok := condition(conditions, rhs, RelationNonNegative)
+		if !ok {
+			return nil
+		}
+		return s.isolate(lhs.Left, binary(OperationExponentiation, rhs, number(2)), conditions)
+	}
+	if failed != nil {
+		return failed
+	}
+	return fmt.Errorf("%w: can't isolate %s in %s", ErrNotSolvable, s.x, lhs)
+}
+
+// Solve solves an equation for a variable, an expression that isn't an
+// equation is equated to zero. Rational equations are solved exactly when
+// their numerators are linear or quadratic in the variable, or factor into
+// such polynomials. Otherwise the variable is isolated by applying inverse
+// operations to both sides. The solutions hold when their conditions on the
+// other variables hold, solutions with conditions known not to hold or that
+// make a denominator of the equation zero are omitted and real solutions are
+// sorted when they are constants.
+func Solve(equation *Node, x string) ([]Solution, error) {
+	if equation == nil {
+		return nil, errors.New("solve: nil equation")
+	}
+	if !identifier.MatchString(x) || x == Reserved {
+		return nil, fmt.Errorf("solve: invalid variable name %q", x)
+	}
+	lhs, rhs := equation, &Node{Operation: OperationNumber, Value: 0}
+	if equation.Operation == OperationEquation {
+		lhs, rhs = equation.Left, equation.Right
+	}
+	variable := map[string]bool{x: true}
+	if !lhs.dependsOn(variable) {
+		lhs, rhs = rhs, lhs
+	}
+	if rhs.dependsOn(variable) {
+		lhs, rhs = &Node{Operation: OperationSubtract, Left: lhs, Right: rhs}, &Node{Operation: OperationNumber, Value: 0}
+	}
+	s := &solver{x: x}
+	s.collect(equation)
+	if err := s.isolate(lhs, rhs, nil); err != nil {
+		return nil, err
+	}
+	for _, solution := range s.solutions {
+		if len(solution.Value.FreeVariables()) > 0 {
+			return s.solutions, nil
+		}
+	}
+	slices.SortStableFunc(s.solutions, func(a, b Solution) int {
+		u, v := a.Value.Calculate(nil), b.Value.Calculate(nil)
+		switch {
+		case u < v:
+			return -1
+		case u > v:
+			return 1
+		}
+		return 0
+	})
+	return s.solutions, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+)
+
+// Reserved is the name of the constant pi, which the parser never reads as
+// a variable
+const Reserved = "pi"
+
+// ErrReservedName is returned when substituting or renaming the constant pi
+var ErrReservedName = errors.New("pi is reserved")
+
+// identifier matches the variable names of the grammar
+var identifier = regexp.MustCompile(`^[a-z]+[0-9]*$`)
+
+// FreeVariables returns the sorted names of the variables of a tree, the
+// constant pi isn't a variable
+func (n *Node) FreeVariables() []string {
+	seen := make(map[string]bool)
+	var process func(n *Node)
+	process = func(n *Node) {
+		if n == nil {
+			return
+		}
+		switch n.Operation {
+		case OperationVariable:
+			seen[n.Variable] = true
+		case OperationIntegral:
+			if n.Right != nil && n.Right.Operation == OperationBounds {
+				// the variable of a definite integral is bound
+				for _, name := range n.Left.FreeVariables() {
+					if name != n.Right.Variable {
+						seen[name] = true
+					}
+				}
+				process(n.Right)
+				return
+			}
+		}
+		process(n.Left)
+		process(n.Right)
+	}
+	process(n)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Substitute replaces the variables of a tree with the trees they map to,
+// all at once so {x: y, y: x} swaps x and y. The replacement trees and
+// unchanged subtrees are shared with the result. The variable of a definite
+// integral is bound and isn't replaced in the integrand, the variable of an
+// indefinite integral can only be replaced with another variable.
+func (n *Node) Substitute(replacements map[string]*Node) (*Node, error) {
+	for name, replacement := range replacements {
+		if name == Reserved {
+			return nil, ErrReservedName
+		}
+		if replacement == nil {
+			return nil, fmt.Errorf("substitute: nil replacement for %s", name)
+		}
+	}
+	var err error
+	var process func(n *Node, replacements map[string]*Node) *Node
+	process = func(n *Node, replacements map[string]*Node) *Node {
+		if n == nil {
+			return nil
+		}
+		if n.Operation == OperationVariable {
+			if replacement, ok := replacements[n.Variable]; ok {
+				return replacement
+			}
+			return n
+		}
+		inner := replacements
+		if n.Operation == OperationIntegral && n.Right != nil {
+			v := n.Right.Variable
+			replacement, ok := replacements[v]
+			switch {
+			case !ok:
+			case n.Right.Operation == OperationBounds:
+				inner = maps.Clone(replacements)
+				delete(inner, v)
+			case replacement.Operation != OperationVariable:
+				err = fmt.Errorf("substitute: %s is the variable of an indefinite integral", v)
+			}
+		}
+		left, right := process(n.Left, inner), process(n.Right, replacements)
+		if left == n.Left && right == n.Right {
+			return n
+		}
+		a := *n
+		a.Left, a.Right = left, right
+		return &a
+	}
+	a := process(n, replacements)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Rename renames the variables of a tree, the new names must be valid
+// variable names other than pi and distinct from each other and the
+// variables that aren't renamed
+func (n *Node) Rename(names map[string]string) (*Node, error) {
+	replacements := make(map[string]*Node, len(names))
+	for from, to := range names {
+		if from == Reserved || to == Reserved {
+			return nil, ErrReservedName
+		}
+		if !identifier.MatchString(to) {
+			return nil, fmt.Errorf("rename: invalid variable name %q", to)
+		}
+		replacements[from] = &Node{Operation: OperationVariable, Variable: to}
+	}
+	renamed := make(map[string]string)
+	for _, name := range n.FreeVariables() {
+		to, ok := names[name]
+		if !ok {
+			to = name
+		}
+		if other, ok := renamed[to]; ok {
+			return nil, fmt.Errorf("rename: %s and %s both become %s", other, name, to)
+		}
+		renamed[to] = name
+	}
+	return n.Substitute(replacements)
+}
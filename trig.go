@@ -0,0 +1,188 @@
+// Copyright 2025 The Feynman Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feynman
+
+import (
+	"errors"
+	"math"
+)
+
+// TrigStrategy selects the trigonometric identities used by SimplifyTrig
+type TrigStrategy uint
+
+const (
+	// TrigPythagorean replaces sin(a)^2 + cos(a)^2 with 1
+	TrigPythagorean TrigStrategy = 1 << iota
+	// TrigParity replaces sin(-a) with -sin(a) and cos(-a) with cos(a)
+	TrigParity
+	// TrigSpecialValues evaluates sin and cos at multiples of pi/6 and pi/4
+	TrigSpecialValues
+	// TrigExpand expands sums and doubles of angles
+	TrigExpand
+	// TrigContract contracts products of sin and cos into sums and doubles
+	// of angles, the inverse of TrigExpand
+	TrigContract
+)
+
+// TrigDefault is the strategies that only simplify
+const TrigDefault = TrigPythagorean | TrigParity | TrigSpecialValues
+
+// ErrTrigStrategy is returned when TrigExpand and TrigContract are both
+// selected, as they undo each other
+var ErrTrigStrategy = errors.New("trig expand and contract are exclusive")
+
+// piMultiple returns k if a tree of numbers and pi equals k*pi/12 for an
+// integer k
+func piMultiple(n *Node) (int, bool) {
+	constant := true
+	var process func(n *Node)
+	process = func(n *Node) {
+		if n == nil || !constant {
+			return
+		}
+		switch n.Operation {
+		case OperationNumber, OperationPI, OperationAdd, OperationSubtract,
+			OperationMultiply, OperationDivide, OperationNegate:
+		default:
+			constant = false
+			return
+		}
+		for _, child := range n.children() {
+			process(child)
+		}
+	}
+	process(n)
+	if !constant {
+		return 0, false
+	}
+	k := n.Calculate(nil) * 12 / math.Pi
+	if math.IsNaN(k) || math.IsInf(k, 0) || math.Abs(k) > 1<<30 {
+		return 0, false
+	}
+	rounded := math.Round(k)
+	if math.Abs(k-rounded) > 1e-9*math.Max(1, math.Abs(k)) {
+		return 0, false
+	}
+	return int(rounded), true
+}
+
+// sine returns sin(k*pi/12) exactly for the multiples of pi/6 and pi/4
+func sine(k int) (*Node, bool) {
+	number := func(v float64) *Node {
+		return &Node{Operation: OperationNumber, Value: v}
+	}
+	half := func(n *Node) *Node {
+		return &Node{Operation: OperationDivide, Left: n, Right: number(2)}
+	}
+	root := func(v float64) *Node {
+		return half(&Node{Operation: OperationSquareRoot, Left: number(v)})
+	}
+	k %= 24
+	if k < 0 {
+		k += 24
+	}
+	negative := k > 12
+	if negative {
+		k -= 12
+	}
+	var a *Node
+	switch k {
+	case 0, 12:
+		return number(0), true
+	case 2, 10:
+		a = half(number(1))
+	case 3, 9:
+		a = root(2)
+	case 4, 8:
+		a = root(3)
+	case 6:
+		a = number(1)
+	default:
+		return nil, false
+	}
+	if negative {
+		a = &Node{Operation: OperationNegate, Left: a}
+	}
+	return a, true
+}
+
+// specialValue creates a rule evaluating sin or cos at special angles, the
+// cosine is the sine shifted by pi/2
+func specialValue(o Operation, shift int) *Rule {
+	return &Rule{
+		Name: o.String() + "(k*pi/12)",
+		LHS:  &Node{Operation: o, Left: &Node{Operation: OperationPattern, Variable: "a"}},
+		Transform: func(b Bindings) *Node {
+			k, ok := piMultiple(b["a"])
+			if !ok {
+				return nil
+			}
+			a, ok := sine(k + shift)
+			if !ok {
+				return nil
+			}
+			return a
+		},
+	}
+}
+
+// TrigRules returns the rewrite rules of the selected strategies
+func TrigRules(strategies TrigStrategy) []*Rule {
+	rules := []*Rule{}
+	if strategies&TrigPythagorean != 0 {
+		rules = append(rules,
+			mustParseRule("sin(?a)^2 + cos(?a)^2 -> 1", nil),
+			mustParseRule("cos(?a)^2 + sin(?a)^2 -> 1", nil),
+			mustParseRule("?c + sin(?a)^2 + cos(?a)^2 -> ?c + 1", nil),
+			mustParseRule("?c + cos(?a)^2 + sin(?a)^2 -> ?c + 1", nil),
+			mustParseRule("?c * sin(?a)^2 + ?c * cos(?a)^2 -> ?c", nil),
+			mustParseRule("?c * cos(?a)^2 + ?c * sin(?a)^2 -> ?c", nil),
+			mustParseRule("1 - sin(?a)^2 -> cos(?a)^2", nil),
+			mustParseRule("1 - cos(?a)^2 -> sin(?a)^2", nil),
+		)
+	}
+	if strategies&TrigParity != 0 {
+		rules = append(rules,
+			mustParseRule("sin(-?a) -> -(sin(?a))", nil),
+			mustParseRule("cos(-?a) -> cos(?a)", nil),
+		)
+	}
+	if strategies&TrigSpecialValues != 0 {
+		rules = append(rules,
+			specialValue(OperationSine, 0),
+			specialValue(OperationCosine, 6),
+		)
+	}
+	if strategies&TrigExpand != 0 {
+		rules = append(rules,
+			mustParseRule("sin(2 * ?a) -> 2 * sin(?a) * cos(?a)", nil),
+			mustParseRule("cos(2 * ?a) -> cos(?a)^2 - sin(?a)^2", nil),
+			mustParseRule("sin(?a + ?b) -> sin(?a) * cos(?b) + cos(?a) * sin(?b)", nil),
+			mustParseRule("sin(?a - ?b) -> sin(?a) * cos(?b) - cos(?a) * sin(?b)", nil),
+			mustParseRule("cos(?a + ?b) -> cos(?a) * cos(?b) - sin(?a) * sin(?b)", nil),
+			mustParseRule("cos(?a - ?b) -> cos(?a) * cos(?b) + sin(?a) * sin(?b)", nil),
+		)
+	}
+	if strategies&TrigContract != 0 {
+		rules = append(rules,
+			mustParseRule("2 * sin(?a) * cos(?a) -> sin(2 * ?a)", nil),
+			mustParseRule("cos(?a)^2 - sin(?a)^2 -> cos(2 * ?a)", nil),
+			mustParseRule("sin(?a) * cos(?b) + cos(?a) * sin(?b) -> sin(?a + ?b)", nil),
+			mustParseRule("sin(?a) * cos(?b) - cos(?a) * sin(?b) -> sin(?a - ?b)", nil),
+			mustParseRule("cos(?a) * cos(?b) - sin(?a) * sin(?b) -> cos(?a + ?b)", nil),
+			mustParseRule("cos(?a) * cos(?b) + sin(?a) * sin(?b) -> cos(?a - ?b)", nil),
+		)
+	}
+	return rules
+}
+
+// SimplifyTrig rewrites a tree with DefaultRules and the trigonometric
+// identities of the selected strategies
+func SimplifyTrig(n *Node, strategies TrigStrategy) (*Node, error) {
+	if strategies&TrigExpand != 0 && strategies&TrigContract != 0 {
+		return nil, ErrTrigStrategy
+	}
+	return NewRewriter(append(DefaultRules(), TrigRules(strategies)...)...).Rewrite(n)
+}